@@ -0,0 +1,659 @@
+package xploader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ANSIPalette holds the 16 colors of the IBM VGA text mode palette used by ANSI art, indexed by ANSI color number: 0-7
+// are the normal colors (black, red, green, brown, blue, magenta, cyan and light gray) and 8-15 their bright variants.
+var ANSIPalette = [16]Color{
+	{R: 0x00, G: 0x00, B: 0x00},
+	{R: 0xAA, G: 0x00, B: 0x00},
+	{R: 0x00, G: 0xAA, B: 0x00},
+	{R: 0xAA, G: 0x55, B: 0x00},
+	{R: 0x00, G: 0x00, B: 0xAA},
+	{R: 0xAA, G: 0x00, B: 0xAA},
+	{R: 0x00, G: 0xAA, B: 0xAA},
+	{R: 0xAA, G: 0xAA, B: 0xAA},
+	{R: 0x55, G: 0x55, B: 0x55},
+	{R: 0xFF, G: 0x55, B: 0x55},
+	{R: 0x55, G: 0xFF, B: 0x55},
+	{R: 0xFF, G: 0xFF, B: 0x55},
+	{R: 0x55, G: 0x55, B: 0xFF},
+	{R: 0xFF, G: 0x55, B: 0xFF},
+	{R: 0x55, G: 0xFF, B: 0xFF},
+	{R: 0xFF, G: 0xFF, B: 0xFF},
+}
+
+const (
+	// ansiDefaultWidth is the canvas width of ANSI art without a SAUCE record.
+	ansiDefaultWidth = 80
+
+	// ansiMaxWidth and ansiDefaultMaxHeight keep bogus SAUCE records and cursor movements from allocating huge canvases.
+	ansiMaxWidth         = 1024
+	ansiDefaultMaxHeight = 10000
+
+	// ansiDefaultFg and ansiDefaultBg are the ANSI color numbers of the initial (and reset) colors.
+	ansiDefaultFg = 7
+	ansiDefaultBg = 0
+
+	// ansiEOF is the DOS end of file marker separating the art from its SAUCE record.
+	ansiEOF = 0x1A
+
+	ansiESC = 0x1B
+
+	sauceSize        = 128
+	sauceCommentSize = 64
+)
+
+// ANSIOptions controls how ANSI art is decoded and encoded.
+type ANSIOptions struct {
+	// Width is the canvas width in characters. When decoding, the width stored in the SAUCE record takes precedence.
+	// Defaults to 80.
+	Width int
+
+	// MaxHeight limits the number of lines a decoded image may have. Defaults to 10000.
+	MaxHeight int
+
+	// ICEColors interprets the blink attribute as a bright background color when decoding, and allows bright
+	// background colors when encoding. When decoding, the iCE colors flag of the SAUCE record also enables it.
+	ICEColors bool
+
+	// SAUCE appends a SAUCE record when encoding. It is always appended when the XPFile carries a title or author.
+	SAUCE bool
+}
+
+// sauce holds the fields of a SAUCE record that are relevant to this package.
+type sauce struct {
+	title, author, group, date string
+	width, height              uint16
+	iceColors                  bool
+}
+
+// DecodeANSI reads ANSI art from r and returns it as an XPFile with a single row-major layer. The data is interpreted
+// as CP437 bytes, see CP437ToUnicode, with SGR color attributes (16 colors, iCE colors, xterm 256 colors and
+// truecolor) and cursor movement sequences. A SAUCE record, when present, provides the canvas width and iCE colors
+// flag, and its title, author, group and date are preserved in the file metadata.
+func DecodeANSI(r io.Reader, opts ANSIOptions) (*XPFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ANSI data: %w", err)
+	}
+
+	data, rec := splitSAUCE(data)
+
+	width := opts.Width
+	iceColors := opts.ICEColors
+	if rec != nil {
+		if rec.width > 0 {
+			width = int(rec.width)
+		}
+		iceColors = iceColors || rec.iceColors
+	}
+	if width <= 0 {
+		width = ansiDefaultWidth
+	}
+	if width > ansiMaxWidth {
+		return nil, fmt.Errorf("canvas width of %d exceeds maximum of %d", width, ansiMaxWidth)
+	}
+
+	maxHeight := opts.MaxHeight
+	if maxHeight <= 0 {
+		maxHeight = ansiDefaultMaxHeight
+	}
+
+	p := &ansiParser{
+		width:     width,
+		maxHeight: maxHeight,
+		iceColors: iceColors,
+	}
+	p.resetAttributes()
+	if err := p.parse(data); err != nil {
+		return nil, err
+	}
+
+	xp := &XPFile{
+		Version: defaultVersion,
+		Layers:  []Layer{*p.layer()},
+	}
+	if rec != nil {
+		xp.Meta = rec.meta()
+	}
+
+	return xp, nil
+}
+
+// ansiParser holds the state of the ANSI art interpreter.
+type ansiParser struct {
+	width, maxHeight int
+	iceColors        bool
+
+	rows         [][]Cell
+	x, y         int
+	savedX       int
+	savedY       int
+	pendingWrap  bool
+	fg, bg       int // ANSI color numbers, or -1 when a 256 or truecolor color is in use
+	fgRGB, bgRGB Color
+	bold, blink  bool
+	inverse      bool
+}
+
+// resetAttributes restores the default colors and attributes.
+func (p *ansiParser) resetAttributes() {
+	p.fg, p.bg = ansiDefaultFg, ansiDefaultBg
+	p.bold, p.blink, p.inverse = false, false, false
+}
+
+// blankCell returns the cell the canvas is initialized with.
+func (p *ansiParser) blankCell() Cell {
+	return Cell{Rune: ' ', Fg: ANSIPalette[ansiDefaultFg], Bg: ANSIPalette[ansiDefaultBg]}
+}
+
+// colors returns the effective foreground and background colors for the current attributes.
+func (p *ansiParser) colors() (Color, Color) {
+	fg := p.fgRGB
+	if p.fg >= 0 {
+		n := p.fg
+		if p.bold && n < 8 {
+			n += 8
+		}
+		fg = ANSIPalette[n]
+	}
+
+	bg := p.bgRGB
+	if p.bg >= 0 {
+		n := p.bg
+		if p.blink && p.iceColors && n < 8 {
+			n += 8
+		}
+		bg = ANSIPalette[n]
+	}
+
+	if p.inverse {
+		return bg, fg
+	}
+	return fg, bg
+}
+
+// moveTo moves the cursor, clamping it to the canvas.
+func (p *ansiParser) moveTo(x, y int) {
+	p.x = min(max(x, 0), p.width-1)
+	p.y = min(max(y, 0), p.maxHeight-1)
+	p.pendingWrap = false
+}
+
+// ensureRow makes sure the canvas has at least y+1 rows.
+func (p *ansiParser) ensureRow(y int) {
+	for len(p.rows) <= y {
+		row := make([]Cell, p.width)
+		for i := range row {
+			row[i] = p.blankCell()
+		}
+		p.rows = append(p.rows, row)
+	}
+}
+
+// put writes a glyph at the cursor and advances it, wrapping at the right edge like a terminal does: the wrap is
+// deferred until the next glyph, so a line of exactly width glyphs followed by CR LF does not produce an empty line.
+func (p *ansiParser) put(r rune) error {
+	if p.pendingWrap {
+		p.x = 0
+		p.y++
+		p.pendingWrap = false
+	}
+	if p.y >= p.maxHeight {
+		return fmt.Errorf("image exceeds maximum height of %d lines", p.maxHeight)
+	}
+
+	p.ensureRow(p.y)
+	fg, bg := p.colors()
+	p.rows[p.y][p.x] = Cell{Rune: r, Fg: fg, Bg: bg}
+
+	if p.x == p.width-1 {
+		p.pendingWrap = true
+	} else {
+		p.x++
+	}
+	return nil
+}
+
+// parse interprets the ANSI data.
+func (p *ansiParser) parse(data []byte) error {
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+
+		switch b {
+		case ansiEOF:
+			return nil
+		case '\r':
+			p.x = 0
+			p.pendingWrap = false
+		case '\n':
+			p.x = 0
+			p.y++
+			p.pendingWrap = false
+			if p.y >= p.maxHeight {
+				return fmt.Errorf("image exceeds maximum height of %d lines", p.maxHeight)
+			}
+			p.ensureRow(p.y - 1)
+		case '\t':
+			p.moveTo((p.x/8+1)*8, p.y)
+		case ansiESC:
+			if i+1 < len(data) && data[i+1] == '[' {
+				n, err := p.csi(data[i+2:])
+				if err != nil {
+					return err
+				}
+				i += 1 + n
+			}
+		default:
+			ru := CP437ToUnicode[int32(b)]
+			if ru == '\x00' {
+				ru = ' '
+			}
+			if err := p.put(ru); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// csi interprets the control sequence at the start of data, which follows "ESC [", and returns the number of bytes it
+// consumed. Unsupported sequences are skipped.
+func (p *ansiParser) csi(data []byte) (int, error) {
+	end := bytes.IndexFunc(data, func(r rune) bool { return r >= 0x40 && r <= 0x7E })
+	if end < 0 {
+		return len(data), nil
+	}
+
+	raw := string(data[:end])
+	final := data[end]
+
+	var params []int
+	if raw != "" && !strings.ContainsAny(raw, "?<=>") {
+		for _, s := range strings.Split(raw, ";") {
+			n, _ := strconv.Atoi(s)
+			params = append(params, n)
+		}
+	}
+	param := func(i, def int) int {
+		if i < len(params) && params[i] > 0 {
+			return params[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'm':
+		p.sgr(params)
+	case 'A':
+		p.moveTo(p.x, p.y-param(0, 1))
+	case 'B':
+		p.moveTo(p.x, p.y+param(0, 1))
+	case 'C':
+		p.moveTo(p.x+param(0, 1), p.y)
+	case 'D':
+		p.moveTo(p.x-param(0, 1), p.y)
+	case 'H', 'f':
+		p.moveTo(param(1, 1)-1, param(0, 1)-1)
+	case 's':
+		p.savedX, p.savedY = p.x, p.y
+	case 'u':
+		p.moveTo(p.savedX, p.savedY)
+	case 'J':
+		if param(0, 0) == 2 {
+			p.rows = nil
+			p.moveTo(0, 0)
+		}
+	case 't':
+		// PabloDraw's 24-bit color extension: ESC [ 0|1 ; R ; G ; B t selects the background or foreground color.
+		if len(params) == 4 {
+			c := Color{R: uint8(params[1]), G: uint8(params[2]), B: uint8(params[3])}
+			if params[0] == 1 {
+				p.fg, p.fgRGB = -1, c
+			} else {
+				p.bg, p.bgRGB = -1, c
+			}
+		}
+	}
+
+	return end + 1, nil
+}
+
+// sgr applies a Select Graphic Rendition sequence.
+func (p *ansiParser) sgr(params []int) {
+	if len(params) == 0 {
+		p.resetAttributes()
+		return
+	}
+
+	for i := 0; i < len(params); i++ {
+		switch n := params[i]; {
+		case n == 0:
+			p.resetAttributes()
+		case n == 1:
+			p.bold = true
+		case n == 5 || n == 6:
+			p.blink = true
+		case n == 7:
+			p.inverse = true
+		case n == 22:
+			p.bold = false
+		case n == 25:
+			p.blink = false
+		case n == 27:
+			p.inverse = false
+		case n >= 30 && n <= 37:
+			p.fg = n - 30
+		case n == 39:
+			p.fg = ansiDefaultFg
+		case n >= 40 && n <= 47:
+			p.bg = n - 40
+		case n == 49:
+			p.bg = ansiDefaultBg
+		case n >= 90 && n <= 97:
+			p.fg = n - 90 + 8
+		case n >= 100 && n <= 107:
+			p.bg = n - 100 + 8
+		case n == 38 || n == 48:
+			c, used, ok := extendedColor(params[i+1:])
+			i += used
+			if !ok {
+				continue
+			}
+			if n == 38 {
+				p.fg, p.fgRGB = -1, c
+			} else {
+				p.bg, p.bgRGB = -1, c
+			}
+		}
+	}
+}
+
+// extendedColor parses the parameters following SGR 38 or 48: "5;n" for an xterm 256 color or "2;r;g;b" for a
+// truecolor. It returns the color, the number of parameters consumed and whether the color was valid.
+func extendedColor(params []int) (Color, int, bool) {
+	if len(params) >= 2 && params[0] == 5 {
+		return XTerm256Color(uint8(params[1])), 2, true
+	}
+	if len(params) >= 4 && params[0] == 2 {
+		return Color{R: uint8(params[1]), G: uint8(params[2]), B: uint8(params[3])}, 4, true
+	}
+	return Color{}, len(params), false
+}
+
+// XTerm256Color returns the color of the given index in the xterm 256 color palette: the 16 ANSI colors, followed by a
+// 6x6x6 color cube and a 24 step grayscale ramp.
+func XTerm256Color(n uint8) Color {
+	switch {
+	case n < 16:
+		return ANSIPalette[n]
+	case n < 232:
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		i := n - 16
+		return Color{R: levels[i/36], G: levels[i/6%6], B: levels[i%6]}
+	}
+	v := 8 + (n-232)*10
+	return Color{R: v, G: v, B: v}
+}
+
+// layer returns the decoded canvas as a row-major layer.
+func (p *ansiParser) layer() *Layer {
+	height := max(len(p.rows), 1)
+	layer := NewEmptyLayer(p.width, height)
+
+	blank := p.blankCell()
+	for i := range layer.Cells {
+		layer.Cells[i] = blank
+	}
+	for y, row := range p.rows {
+		copy(layer.Row(y), row)
+	}
+
+	return layer
+}
+
+// splitSAUCE separates the SAUCE record and optional comment block from the art. It returns the art data and the
+// parsed record, or the unmodified data and nil when there is no SAUCE record.
+func splitSAUCE(data []byte) ([]byte, *sauce) {
+	if len(data) < sauceSize {
+		return data, nil
+	}
+	raw := data[len(data)-sauceSize:]
+	if !bytes.HasPrefix(raw, []byte("SAUCE00")) {
+		return data, nil
+	}
+
+	rec := &sauce{
+		title:     decodeCP437Field(raw[7:42]),
+		author:    decodeCP437Field(raw[42:62]),
+		group:     decodeCP437Field(raw[62:82]),
+		date:      decodeCP437Field(raw[82:90]),
+		iceColors: raw[105]&0x01 != 0,
+	}
+
+	// Character (1) and BinaryText-like (ANSi etc.) data types store the dimensions in TInfo1 and TInfo2.
+	if raw[94] == 1 {
+		rec.width = binary.LittleEndian.Uint16(raw[96:98])
+		rec.height = binary.LittleEndian.Uint16(raw[98:100])
+	}
+
+	art := data[:len(data)-sauceSize]
+	if comments := int(raw[104]); comments > 0 {
+		size := 5 + comments*sauceCommentSize
+		if len(art) >= size && bytes.HasPrefix(art[len(art)-size:], []byte("COMNT")) {
+			art = art[:len(art)-size]
+		}
+	}
+
+	return art, rec
+}
+
+// meta returns the SAUCE fields worth preserving as file metadata, or nil when they are all empty.
+func (s *sauce) meta() *FileMeta {
+	m := &FileMeta{Title: s.title, Author: s.author}
+	if s.group != "" || s.date != "" {
+		m.Properties = map[string]string{}
+		if s.group != "" {
+			m.Properties["group"] = s.group
+		}
+		if s.date != "" {
+			m.Properties["date"] = s.date
+		}
+	}
+
+	if m.Title == "" && m.Author == "" && m.Properties == nil {
+		return nil
+	}
+	return m
+}
+
+// decodeCP437Field decodes a space or NUL padded CP437 SAUCE field.
+func decodeCP437Field(b []byte) string {
+	b = bytes.TrimRight(b, " \x00")
+
+	var sb strings.Builder
+	for _, c := range b {
+		sb.WriteRune(CP437ToUnicode[int32(c)])
+	}
+	return sb.String()
+}
+
+// encodeCP437Field encodes s as a space padded CP437 SAUCE field of the given size, truncating it when needed.
+func encodeCP437Field(s string, size int) []byte {
+	b := bytes.Repeat([]byte{' '}, size)
+
+	i := 0
+	for _, r := range s {
+		if i == size {
+			break
+		}
+		b[i] = ansiByte(r)
+		i++
+	}
+	return b
+}
+
+// ansiByte returns the CP437 byte for the rune. Runes without a CP437 equivalent and bytes that would be interpreted
+// as control characters by DecodeANSI are replaced with a space.
+func ansiByte(r rune) byte {
+	code, ok := UnicodeToCP437[r]
+	switch {
+	case !ok:
+		return ' '
+	case code == '\t', code == '\n', code == '\r', code == ansiEOF, code == ansiESC:
+		return ' '
+	}
+	return byte(code)
+}
+
+// EncodeANSI writes the XPFile to w as ANSI art using 16 color SGR sequences, or bright backgrounds too when
+// opts.ICEColors is set. Like REXPaint's own exports, the layers are flattened into a single image first. Colors are
+// mapped to the nearest ANSIPalette color, InvisibleColor backgrounds become black and glyphs with an invisible
+// foreground become spaces. A SAUCE record holding the file's title and author is appended when opts.SAUCE is set or
+// the XPFile carries either of them.
+func EncodeANSI(w io.Writer, xp *XPFile, opts ANSIOptions) error {
+	layer := xp.Flatten()
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	maxBg := 8
+	if opts.ICEColors {
+		maxBg = 16
+	}
+
+	fg, bg := -1, -1
+	for y := 0; y < int(layer.Height); y++ {
+		for x := 0; x < int(layer.Width); x++ {
+			cell := layer.At(x, y)
+
+			r := cell.Rune
+			cfg, cbg := ansiDefaultFg, ansiDefaultBg
+			if !cell.Bg.IsInvisible() {
+				cbg = NearestPaletteIndex(cell.Bg, ANSIPalette[:maxBg])
+			}
+			if cell.Fg.IsInvisible() {
+				r = ' '
+			} else {
+				cfg = NearestPaletteIndex(cell.Fg, ANSIPalette[:])
+			}
+
+			if cfg != fg || cbg != bg {
+				fmt.Fprint(cw, sgrSequence(cfg, cbg))
+				fg, bg = cfg, cbg
+			}
+			cw.Write([]byte{ansiByte(r)})
+		}
+		fmt.Fprint(cw, "\x1b[0m\r\n")
+		fg, bg = -1, -1
+	}
+
+	size := cw.n
+	if opts.SAUCE || (xp.Meta != nil && (xp.Meta.Title != "" || xp.Meta.Author != "")) {
+		cw.Write([]byte{ansiEOF})
+		cw.Write(sauceRecord(xp.Meta, layer, size, opts.ICEColors))
+	}
+
+	if cw.err != nil {
+		return fmt.Errorf("failed to write ANSI data: %w", cw.err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write ANSI data: %w", err)
+	}
+
+	return nil
+}
+
+// sgrSequence returns the SGR sequence selecting the given ANSI foreground and background color numbers.
+func sgrSequence(fg, bg int) string {
+	var sb strings.Builder
+	sb.WriteString("\x1b[0")
+	if fg >= 8 {
+		sb.WriteString(";1")
+	}
+	if bg >= 8 {
+		sb.WriteString(";5")
+	}
+	fmt.Fprintf(&sb, ";%d;%dm", 30+fg%8, 40+bg%8)
+	return sb.String()
+}
+
+// sauceRecord builds a SAUCE record for an ANSi file.
+func sauceRecord(meta *FileMeta, layer *Layer, size int64, iceColors bool) []byte {
+	var title, author, group, date string
+	if meta != nil {
+		title, author = meta.Title, meta.Author
+		group, date = meta.Properties["group"], meta.Properties["date"]
+	}
+
+	rec := make([]byte, 0, sauceSize)
+	rec = append(rec, "SAUCE00"...)
+	rec = append(rec, encodeCP437Field(title, 35)...)
+	rec = append(rec, encodeCP437Field(author, 20)...)
+	rec = append(rec, encodeCP437Field(group, 20)...)
+	rec = append(rec, encodeCP437Field(date, 8)...)
+	rec = binary.LittleEndian.AppendUint32(rec, uint32(size))
+	rec = append(rec, 1, 1) // DataType Character, FileType ANSi.
+	rec = binary.LittleEndian.AppendUint16(rec, uint16(layer.Width))
+	rec = binary.LittleEndian.AppendUint16(rec, uint16(layer.Height))
+	rec = binary.LittleEndian.AppendUint16(rec, 0)
+	rec = binary.LittleEndian.AppendUint16(rec, 0)
+	rec = append(rec, 0) // No comments.
+
+	var flags byte
+	if iceColors {
+		flags |= 0x01
+	}
+	rec = append(rec, flags)
+
+	return append(rec, make([]byte, sauceSize-len(rec))...)
+}
+
+// NearestPaletteIndex returns the index of the palette color closest to c, using a weighted euclidean distance that
+// approximates human color perception.
+func NearestPaletteIndex(c Color, palette []Color) int {
+	best, bestDist := 0, -1
+	for i, p := range palette {
+		if d := colorDistance(c, p); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// colorDistance returns the squared "redmean" distance between two colors.
+func colorDistance(a, b Color) int {
+	rm := (int(a.R) + int(b.R)) / 2
+	dr := int(a.R) - int(b.R)
+	dg := int(a.G) - int(b.G)
+	db := int(a.B) - int(b.B)
+	return ((512+rm)*dr*dr)>>8 + 4*dg*dg + ((767-rm)*db*db)>>8
+}
+
+// countingWriter counts the bytes written through it and remembers the first error, so callers can check it once.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// Write implements io.Writer.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,165 @@
+package xploader
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeANSIColorsAndCursor(t *testing.T) {
+	data := "\x1b[1;31mA\x1b[0;44mB\x1b[2CC\r\n\x1b[5;42mD\x1b[1;1HE\xdb\x1b[38;2;1;2;3mF"
+
+	xp, err := DecodeANSI(strings.NewReader(data), ANSIOptions{Width: 8})
+	if err != nil {
+		t.Fatalf("Failed to decode ANSI: %v", err)
+	}
+
+	layer := &xp.Layers[0]
+	if layer.Width != 8 || layer.Height != 2 {
+		t.Fatalf("Dimensions: got %dx%d, want 8x2", layer.Width, layer.Height)
+	}
+
+	tests := []struct {
+		x, y int
+		want Cell
+	}{
+		{0, 0, Cell{'E', ANSIPalette[7], ANSIPalette[2]}}, // Attributes survive cursor movement.
+		{1, 0, Cell{'█', ANSIPalette[7], ANSIPalette[2]}},
+		{2, 0, Cell{'F', Color{1, 2, 3}, ANSIPalette[2]}},
+		{4, 0, Cell{'C', ANSIPalette[7], ANSIPalette[4]}},
+		{3, 0, Cell{' ', ANSIPalette[7], ANSIPalette[0]}},
+		{0, 1, Cell{'D', ANSIPalette[7], ANSIPalette[2]}}, // Blink is ignored without iCE colors.
+	}
+	for _, tt := range tests {
+		if got := layer.At(tt.x, tt.y); got != tt.want {
+			t.Errorf("Cell (%d,%d): got %+v, want %+v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeANSIBoldAndICEColors(t *testing.T) {
+	xp, err := DecodeANSI(strings.NewReader("\x1b[1;5;33;41mX"), ANSIOptions{ICEColors: true})
+	if err != nil {
+		t.Fatalf("Failed to decode ANSI: %v", err)
+	}
+
+	want := Cell{'X', ANSIPalette[11], ANSIPalette[9]}
+	if got := xp.Layers[0].At(0, 0); got != want {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeANSIDeferredWrap(t *testing.T) {
+	xp, err := DecodeANSI(strings.NewReader("abcd\r\nefgh\r\n"), ANSIOptions{Width: 4})
+	if err != nil {
+		t.Fatalf("Failed to decode ANSI: %v", err)
+	}
+	if h := xp.Layers[0].Height; h != 2 {
+		t.Errorf("Height: got %d, want 2", h)
+	}
+}
+
+func TestDecodeANSIMaxHeight(t *testing.T) {
+	_, err := DecodeANSI(strings.NewReader("\x1b[999Bx"), ANSIOptions{MaxHeight: 100})
+	if err != nil {
+		t.Fatalf("Cursor movement should be clamped, got: %v", err)
+	}
+
+	_, err = DecodeANSI(strings.NewReader(strings.Repeat("\r\n", 200)), ANSIOptions{MaxHeight: 100})
+	if err == nil {
+		t.Error("Expected an error for an image exceeding the maximum height")
+	}
+}
+
+func TestANSIRoundTrip(t *testing.T) {
+	layer := NewEmptyLayer(5, 3)
+	for i := range layer.Cells {
+		layer.Cells[i] = Cell{
+			Rune: CP437Decoder(int32(i%254) + 1),
+			Fg:   ANSIPalette[i%16],
+			Bg:   ANSIPalette[i%8],
+		}
+	}
+	// Glyphs that would be interpreted as control characters are written as spaces.
+	for _, code := range []int32{'\t', '\n', '\r', ansiEOF, ansiESC} {
+		for i := range layer.Cells {
+			if layer.Cells[i].Rune == CP437Decoder(code) {
+				layer.Cells[i].Rune = ' '
+			}
+		}
+	}
+
+	xp := newXpFile(*layer, t)
+	xp.Meta = &FileMeta{Title: "Round trip", Author: "xploader", Properties: map[string]string{"group": "test"}}
+
+	var buf bytes.Buffer
+	if err := EncodeANSI(&buf, xp, ANSIOptions{}); err != nil {
+		t.Fatalf("Failed to encode ANSI: %v", err)
+	}
+
+	got, err := DecodeANSI(&buf, ANSIOptions{Width: 40})
+	if err != nil {
+		t.Fatalf("Failed to decode ANSI: %v", err)
+	}
+
+	assertXPFileEqual(xp, got, t)
+}
+
+func TestEncodeANSIQuantizesColors(t *testing.T) {
+	layer := NewEmptyLayer(3, 1)
+	layer.Set(0, 0, Cell{'a', Color{250, 90, 80}, Color{0, 0, 160}})
+	layer.Set(1, 0, Cell{'b', InvisibleColor, InvisibleColor})
+	layer.Set(2, 0, Cell{'c', Color{250, 250, 250}, Color{250, 250, 90}})
+
+	var buf bytes.Buffer
+	if err := EncodeANSI(&buf, newXpFile(*layer, t), ANSIOptions{ICEColors: true}); err != nil {
+		t.Fatalf("Failed to encode ANSI: %v", err)
+	}
+
+	want := "\x1b[0;1;31;44ma\x1b[0;37;40m \x1b[0;1;5;37;43mc\x1b[0m\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestANSIByte(t *testing.T) {
+	tests := map[rune]byte{
+		'A': 'A',
+		'░': 0xB0,
+		'☺': 0x01,
+		'◙': ' ', // CP437 0x0A, a line feed.
+		'←': ' ', // CP437 0x1B, an escape.
+		'¤': ' ', // Below 256, but not in CP437.
+		'😀': ' ',
+	}
+	for r, want := range tests {
+		if got := ansiByte(r); got != want {
+			t.Errorf("ansiByte(%q): got %#x, want %#x", r, got, want)
+		}
+	}
+}
+
+func TestNearestPaletteIndex(t *testing.T) {
+	for i, c := range ANSIPalette {
+		if got := NearestPaletteIndex(c, ANSIPalette[:]); got != i {
+			t.Errorf("Palette color %d: got index %d", i, got)
+		}
+	}
+}
+
+func TestXTerm256Color(t *testing.T) {
+	tests := map[uint8]Color{
+		1:   ANSIPalette[1],
+		16:  {0, 0, 0},
+		21:  {0, 0, 255},
+		196: {255, 0, 0},
+		231: {255, 255, 255},
+		232: {8, 8, 8},
+		255: {238, 238, 238},
+	}
+	for n, want := range tests {
+		if got := XTerm256Color(n); got != want {
+			t.Errorf("XTerm256Color(%d): got %v, want %v", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package xploader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// BackupSuffix is appended to the target path to name the backup of the previous version when SaveOptions.Backup
+	// is enabled.
+	BackupSuffix = ".bak"
+
+	// defaultFileMode is applied to newly created files when saving atomically, matching what most tools create.
+	defaultFileMode fs.FileMode = 0o644
+)
+
+// writeFile creates or replaces the file at path with the data written by the write function, honouring the Backup and
+// Atomic settings of the SaveOptions.
+func writeFile(path string, opts SaveOptions, write func(io.Writer) error) error {
+	if opts.Backup {
+		if err := backupFile(path); err != nil {
+			return err
+		}
+	}
+
+	if opts.Atomic {
+		return writeFileAtomic(path, write)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+
+	return nil
+}
+
+// removeFile removes the file at path, honouring the Backup setting of the SaveOptions. A missing file is not an error.
+func removeFile(path string, opts SaveOptions) error {
+	if opts.Backup {
+		if err := backupFile(path); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file next to path, flushes it to stable storage and renames it over path. The
+// target is either fully replaced or left untouched.
+func writeFileAtomic(path string, write func(io.Writer) error) (err error) {
+	mode := defaultFileMode
+	if fi, statErr := os.Stat(path); statErr == nil {
+		mode = fi.Mode().Perm()
+	} else if !errors.Is(statErr, fs.ErrNotExist) {
+		return fmt.Errorf("failed to stat output file: %w", statErr)
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace output file: %w", err)
+	}
+
+	syncDir(dir)
+
+	return nil
+}
+
+// syncDir flushes the directory entry so the rename survives a crash. Not all platforms support syncing directories,
+// so this is best effort only.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+
+	_ = d.Sync()
+}
+
+// backupFile copies the file at path to path+BackupSuffix, preserving its permissions. A missing file is not an error:
+// there simply is nothing to back up.
+func backupFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open file for backup: %w", err)
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file for backup: %w", err)
+	}
+
+	dst, err := os.OpenFile(path+BackupSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := dst.Sync(); err != nil {
+		return fmt.Errorf("failed to sync backup file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to close backup file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package xploader
+
+import (
+	"bytes"
+	"compress/flate"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSaveAtomicPreservesPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions not supported on Windows")
+	}
+
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "simple.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "perm.xp")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("Failed to create target file: %v", err)
+	}
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatalf("Failed to chmod target file: %v", err)
+	}
+
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file: %v", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat saved file: %v", err)
+	}
+	if fi.Mode().Perm() != 0o640 {
+		t.Errorf("Expected permissions %o, got %o", 0o640, fi.Mode().Perm())
+	}
+
+	xpReloaded, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reload saved XP file: %v", err)
+	}
+	assertXPFileEqual(xp, xpReloaded, t)
+}
+
+func TestSaveAtomicFailureKeepsOriginal(t *testing.T) {
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "simple.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keep.xp")
+	original := []byte("original contents")
+	if err := os.WriteFile(path, original, 0o644); err != nil {
+		t.Fatalf("Failed to create target file: %v", err)
+	}
+
+	// An invalid compression level makes the save fail before any data is written.
+	err = SaveXPFileWithOptions(xp, path, SaveOptions{Gzip: true, GzipLevel: 42, Atomic: true})
+	if err == nil {
+		t.Fatal("Expected error for invalid gzip level, got nil")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read target file: %v", err)
+	}
+	if !bytes.Equal(original, data) {
+		t.Error("Expected target file to be left untouched after failed save")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected temporary file to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func TestSaveBackup(t *testing.T) {
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "simple.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.xp")
+	original := []byte("previous version")
+
+	for _, atomic := range []bool{true, false} {
+		if err := os.WriteFile(path, original, 0o644); err != nil {
+			t.Fatalf("Failed to create target file: %v", err)
+		}
+
+		opts := SaveOptions{Gzip: true, GzipLevel: flate.BestSpeed, RuneEncoder: CP437Encoder, Atomic: atomic, Backup: true}
+		if err := SaveXPFileWithOptions(xp, path, opts); err != nil {
+			t.Fatalf("Atomic=%v: failed to save XP file: %v", atomic, err)
+		}
+
+		backup, err := os.ReadFile(path + BackupSuffix)
+		if err != nil {
+			t.Fatalf("Atomic=%v: failed to read backup file: %v", atomic, err)
+		}
+		if !bytes.Equal(original, backup) {
+			t.Errorf("Atomic=%v: backup does not contain the previous version", atomic)
+		}
+
+		if _, err := LoadXPFile(path); err != nil {
+			t.Errorf("Atomic=%v: failed to reload saved XP file: %v", atomic, err)
+		}
+	}
+}
+
+func TestSaveBackupWithoutExistingFile(t *testing.T) {
+	xp := newXpFile(*NewEmptyLayer(2, 2), t)
+
+	path := filepath.Join(t.TempDir(), "new.xp")
+	if err := SaveXPFileWithOptions(xp, path, SaveOptions{Atomic: true, Backup: true}); err != nil {
+		t.Fatalf("Failed to save XP file: %v", err)
+	}
+
+	if _, err := os.Stat(path + BackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("Expected no backup file when there was no previous version, got err=%v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package xploader
+
+import (
+	"image"
+	"testing"
+)
+
+func TestLayerBlitClipping(t *testing.T) {
+	tests := []struct {
+		name       string
+		srcRect    image.Rectangle
+		dstX, dstY int
+		want       string
+	}{
+		{"whole", image.Rectangle{}, 1, 1, "....\n.ab.\n.cd.\n"},
+		{"rect", image.Rect(1, 0, 2, 2), 0, 0, "b...\nd...\n....\n"},
+		{"negative destination", image.Rectangle{}, -1, -1, "d...\n....\n....\n"},
+		{"past the edge", image.Rectangle{}, 3, 2, "....\n....\n...a\n"},
+		{"rect outside source", image.Rect(-1, -1, 1, 1), 0, 0, "....\n.a..\n....\n"},
+		{"fully outside", image.Rectangle{}, 4, 0, "....\n....\n....\n"},
+		{"zero width rect", image.Rect(1, 0, 1, 2), 0, 0, "....\n....\n....\n"},
+		{"zero height rect", image.Rect(0, 1, 2, 1), 0, 0, "....\n....\n....\n"},
+		{"inverted rect", image.Rectangle{Min: image.Pt(2, 2), Max: image.Pt(0, 0)}, 0, 0, "....\n....\n....\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := textLayer(t, "....\n....\n....")
+			dst.Blit(textLayer(t, "ab\ncd"), tt.srcRect, tt.dstX, tt.dstY, BlitReplace)
+			if got := dst.Text(); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayerBlitLayouts(t *testing.T) {
+	rows := textLayer(t, "ab\ncd")
+	src := &Layer{ColumnMajor: true, Width: 2, Height: 2, Cells: []Cell{rows.At(0, 0), rows.At(0, 1), rows.At(1, 0), rows.At(1, 1)}}
+
+	// All cells are the same, so the layout can be switched without reordering them.
+	dst := textLayer(t, "...\n...")
+	dst.ColumnMajor = true
+
+	dst.Blit(src, image.Rectangle{}, 1, 0, BlitReplace)
+	if got, want := dst.Text(), ".ab\n.cd\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestLayerBlitOntoItself(t *testing.T) {
+	layer := textLayer(t, "abcd")
+	layer.Blit(layer, image.Rect(0, 0, 3, 1), 1, 0, BlitReplace)
+	if got, want := layer.Text(), "aabc\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestLayerBlitModes(t *testing.T) {
+	red, green, blue := Color{R: 255}, Color{G: 255}, Color{B: 255}
+	base := Cell{Rune: 'd', Fg: red, Bg: red}
+
+	tests := []struct {
+		name string
+		src  Cell
+		mode BlitMode
+		want Cell
+	}{
+		{"replace", Cell{Rune: 's', Fg: green, Bg: blue}, BlitReplace, Cell{Rune: 's', Fg: green, Bg: blue}},
+		{"replace empty", NewEmptyCell(), BlitReplace, NewEmptyCell()},
+		{"skip empty", NewEmptyCell(), BlitSkipEmpty, base},
+		{"skip empty opaque", Cell{Rune: 's', Fg: green, Bg: blue}, BlitSkipEmpty, Cell{Rune: 's', Fg: green, Bg: blue}},
+		{"skip empty transparent background", Cell{Rune: 's', Fg: green, Bg: InvisibleColor}, BlitSkipEmpty, Cell{Rune: 's', Fg: green, Bg: red}},
+		{"glyph only", Cell{Rune: 's', Fg: green, Bg: blue}, BlitGlyphOnly, Cell{Rune: 's', Fg: red, Bg: red}},
+		{"glyph only space", Cell{Rune: ' ', Fg: green, Bg: blue}, BlitGlyphOnly, base},
+		{"colors only", Cell{Rune: 's', Fg: green, Bg: blue}, BlitColorsOnly, Cell{Rune: 'd', Fg: green, Bg: blue}},
+		{"colors only invisible", Cell{Rune: 's', Fg: InvisibleColor, Bg: blue}, BlitColorsOnly, Cell{Rune: 'd', Fg: red, Bg: blue}},
+		{"colors only empty", NewEmptyCell(), BlitColorsOnly, base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := NewEmptyLayer(1, 1)
+			dst.Fill(base)
+			src := NewEmptyLayer(1, 1)
+			src.Fill(tt.src)
+
+			dst.Blit(src, image.Rectangle{}, 0, 0, tt.mode)
+			if got := dst.At(0, 0); got != tt.want {
+				t.Errorf("Got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package xploader
+
+// LineWeight is the weight of a line leaving one side of a box drawing glyph.
+type LineWeight uint8
+
+const (
+	LineNone LineWeight = iota
+	LineSingle
+	LineDouble
+)
+
+// BoxSides holds the weight of the lines leaving each side of a box drawing glyph.
+type BoxSides struct {
+	Up, Right, Down, Left LineWeight
+}
+
+// boxGlyphs maps every CP437 box drawing glyph to the lines leaving its sides.
+var boxGlyphs = map[rune]BoxSides{
+	'│': {1, 0, 1, 0}, '─': {0, 1, 0, 1}, '║': {2, 0, 2, 0}, '═': {0, 2, 0, 2},
+	'┌': {0, 1, 1, 0}, '┐': {0, 0, 1, 1}, '└': {1, 1, 0, 0}, '┘': {1, 0, 0, 1},
+	'├': {1, 1, 1, 0}, '┤': {1, 0, 1, 1}, '┬': {0, 1, 1, 1}, '┴': {1, 1, 0, 1}, '┼': {1, 1, 1, 1},
+	'╔': {0, 2, 2, 0}, '╗': {0, 0, 2, 2}, '╚': {2, 2, 0, 0}, '╝': {2, 0, 0, 2},
+	'╠': {2, 2, 2, 0}, '╣': {2, 0, 2, 2}, '╦': {0, 2, 2, 2}, '╩': {2, 2, 0, 2}, '╬': {2, 2, 2, 2},
+	'╒': {0, 2, 1, 0}, '╕': {0, 0, 1, 2}, '╘': {1, 2, 0, 0}, '╛': {1, 0, 0, 2},
+	'╞': {1, 2, 1, 0}, '╡': {1, 0, 1, 2}, '╤': {0, 2, 1, 2}, '╧': {1, 2, 0, 2}, '╪': {1, 2, 1, 2},
+	'╓': {0, 1, 2, 0}, '╖': {0, 0, 2, 1}, '╙': {2, 1, 0, 0}, '╜': {2, 0, 0, 1},
+	'╟': {2, 1, 2, 0}, '╢': {2, 0, 2, 1}, '╥': {0, 1, 2, 1}, '╨': {2, 1, 0, 1}, '╫': {2, 1, 2, 1},
+}
+
+// boxRunes is the inverse of boxGlyphs.
+var boxRunes = func() map[BoxSides]rune {
+	m := make(map[BoxSides]rune, len(boxGlyphs))
+	for r, s := range boxGlyphs {
+		m[s] = r
+	}
+	return m
+}()
+
+// BoxGlyphSides returns the lines leaving the sides of a CP437 box drawing glyph. It returns false when the rune is
+// not a box drawing glyph.
+func BoxGlyphSides(r rune) (BoxSides, bool) {
+	s, ok := boxGlyphs[r]
+	return s, ok
+}
+
+// BoxGlyph returns the CP437 box drawing glyph with the given lines leaving its sides. It returns false when CP437
+// has no such glyph: it only has glyphs whose vertical lines share the same weight, as do its horizontal lines, and
+// that connect at least two sides.
+func BoxGlyph(s BoxSides) (rune, bool) {
+	r, ok := boxRunes[s]
+	return r, ok
+}
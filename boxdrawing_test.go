@@ -0,0 +1,24 @@
+package xploader
+
+import "testing"
+
+func TestBoxGlyph(t *testing.T) {
+	for r, s := range boxGlyphs {
+		if got, ok := BoxGlyph(s); !ok || got != r {
+			t.Errorf("BoxGlyph(%+v): got %q, want %q", s, got, r)
+		}
+		if _, ok := UnicodeToCP437[r]; !ok {
+			t.Errorf("Box drawing glyph %q is not in CP437", r)
+		}
+	}
+
+	if s, ok := BoxGlyphSides('╟'); !ok || s != (BoxSides{Up: LineDouble, Right: LineSingle, Down: LineDouble}) {
+		t.Errorf("BoxGlyphSides('╟'): got %+v, %v", s, ok)
+	}
+	if _, ok := BoxGlyphSides('x'); ok {
+		t.Error("BoxGlyphSides('x') should fail")
+	}
+	if _, ok := BoxGlyph(BoxSides{Up: LineSingle, Down: LineDouble}); ok {
+		t.Error("BoxGlyph should fail for mixed weights along an axis")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/malc0mn/xploder"
+	"github.com/malc0mn/xploder/render/ansi"
+)
+
+var (
+	// removedColor highlights changed cells in the old file.
+	removedColor = xploader.Color{R: 170}
+	// addedColor highlights changed cells in the new file.
+	addedColor = xploader.Color{G: 170}
+)
+
+// runDiff compares two XP files and renders every layer that differs side by side, old on the left and new on the
+// right, with the changed cells highlighted. It returns the exit status: 0 when the files are equal, 1 when they
+// differ, like diff(1).
+func runDiff(oldPath, newPath string) int {
+	a, err := xploader.LoadXPFile(oldPath)
+	if err != nil {
+		log.Fatalf("Failed to load XP file: %v", err)
+	}
+	b, err := xploader.LoadXPFile(newPath)
+	if err != nil {
+		log.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	res := xploader.Diff(a, b)
+	fmt.Printf("--- %s\n+++ %s\n\n", oldPath, newPath)
+
+	for _, d := range res.Layers {
+		fmt.Printf("Layer %d:", d.Index)
+		if d.Resized() {
+			fmt.Printf(" resized %dx%d -> %dx%d,", d.OldWidth, d.OldHeight, d.NewWidth, d.NewHeight)
+		}
+		fmt.Printf(" %d changed cells\n", len(d.Changes))
+		printSideBySide(
+			highlight(&a.Layers[d.Index], d.Changes, removedColor),
+			highlight(&b.Layers[d.Index], d.Changes, addedColor),
+		)
+		fmt.Println()
+	}
+	for _, i := range res.RemovedLayers {
+		fmt.Printf("Layer %d: removed\n", i)
+		printSideBySide(&a.Layers[i], nil)
+		fmt.Println()
+	}
+	for _, i := range res.AddedLayers {
+		fmt.Printf("Layer %d: added\n", i)
+		printSideBySide(nil, &b.Layers[i])
+		fmt.Println()
+	}
+
+	fmt.Printf("Summary: %s\n", res.Summary)
+	if res.Equal() {
+		return 0
+	}
+	return 1
+}
+
+// highlight returns a copy of the layer with the background of the changed cells within its bounds set to c.
+func highlight(layer *xploader.Layer, changes []xploader.CellChange, c xploader.Color) *xploader.Layer {
+	out := layer.Clone()
+	for _, ch := range changes {
+		if cell, ok := out.TryGetCell(ch.X, ch.Y); ok {
+			cell.Bg = c
+			out.SetCell(ch.X, ch.Y, cell)
+		}
+	}
+	return out
+}
+
+// printSideBySide renders both layers next to each other. A nil layer leaves its side blank.
+func printSideBySide(left, right *xploader.Layer) {
+	l, lw := renderLines(left)
+	r, _ := renderLines(right)
+
+	for i := 0; i < max(len(l), len(r)); i++ {
+		line := strings.Repeat(" ", lw)
+		if i < len(l) {
+			line = l[i]
+		}
+		if i < len(r) {
+			if lw > 0 {
+				line += "  "
+			}
+			line += r[i]
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+// renderLines renders the layer framed by a box and returns the lines along with their width in cells.
+func renderLines(layer *xploader.Layer) ([]string, int) {
+	if layer == nil {
+		return nil, 0
+	}
+
+	var buf bytes.Buffer
+	opts := ansi.Options{Mode: ansi.DetectColorMode(), Border: true}
+	if err := ansi.WriteLayer(&buf, layer, opts); err != nil {
+		log.Fatalf("Failed to render layer: %v", err)
+	}
+
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), int(layer.Width) + 2
+}
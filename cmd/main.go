@@ -5,15 +5,21 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/malc0mn/xploder"
+	"github.com/malc0mn/xploder/render/ansi"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <file.xp>\n", filepath.Base(os.Args[0]))
-		os.Exit(1)
+		usage()
+	}
+
+	if os.Args[1] == "diff" {
+		if len(os.Args) != 4 {
+			usage()
+		}
+		os.Exit(runDiff(os.Args[2], os.Args[3]))
 	}
 
 	path := os.Args[1]
@@ -49,39 +55,26 @@ func main() {
 
 	for layerIndex, layer := range xp.Layers {
 		fmt.Printf("Layer %d (%dx%d):\n", layerIndex, layer.Width, layer.Height)
+		printLayer(&layer)
+		fmt.Println()
+	}
 
-		height := int(layer.Height)
-		width := int(layer.Width)
-
-		fmt.Println("┌" + strings.Repeat("─", width) + "┐")
-		for y := 0; y < height; y++ {
-			fmt.Print("│")
-			for x := 0; x < width; x++ {
-				cell := layer.Cells[y][x]
-
-				if cell.IsEmpty() {
-					fmt.Print("\033[0m ")
-					continue
-				}
-
-				// Set foreground and background colors.
-				if !cell.Fg.IsInvisible() {
-					fmt.Printf("\033[38;2;%d;%d;%dm", cell.Fg.R, cell.Fg.G, cell.Fg.B) // Foreground
-				}
-				if !cell.Bg.IsInvisible() {
-					fmt.Printf("\033[48;2;%d;%d;%dm", cell.Bg.R, cell.Bg.G, cell.Bg.B) // Background
-				}
-
-				// Print rune.
-				fmt.Printf("%c", cell.Rune)
+	flat := xp.Flatten()
+	fmt.Printf("Flattened (%dx%d):\n", flat.Width, flat.Height)
+	printLayer(flat)
+}
 
-				// Optionally: reset color after each rune (or after each line for optimization).
-				fmt.Print("\033[0m")
-			}
-			fmt.Println("│")
-		}
-		fmt.Println("└" + strings.Repeat("─", width) + "┘")
+// usage prints how to invoke the command and exits.
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s <file.xp>\n       %s diff <old.xp> <new.xp>\n", name, name)
+	os.Exit(2)
+}
 
-		fmt.Println()
+// printLayer renders the layer to stdout, framed by a box, using the best colors the terminal supports.
+func printLayer(layer *xploader.Layer) {
+	opts := ansi.Options{Mode: ansi.DetectColorMode(), Border: true}
+	if err := ansi.WriteLayer(os.Stdout, layer, opts); err != nil {
+		log.Fatalf("Failed to render layer: %v", err)
 	}
 }
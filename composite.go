@@ -0,0 +1,69 @@
+package xploader
+
+// Flatten composites all layers of the XPFile that are not hidden into a single new layer, exactly like REXPaint renders
+// them on screen. See Composite for the transparency rules.
+func (xp *XPFile) Flatten() *Layer {
+	layers := make([]*Layer, 0, len(xp.Layers))
+	for i := range xp.Layers {
+		if !xp.Layers[i].IsHidden() {
+			layers = append(layers, &xp.Layers[i])
+		}
+	}
+	return Composite(layers...)
+}
+
+// Composite stacks the given layers, the first one being the bottom layer, and returns the result as a new layer. The
+// result is as large as the largest layer and uses the memory layout of the first layer. None of the given layers are
+// modified.
+//
+// REXPaint's transparency rules apply to every cell of a layer above another one:
+//   - A cell with an InvisibleColor background and no visible glyph is fully transparent: the cell below shows
+//     through.
+//   - A cell with an InvisibleColor background and a visible glyph is partially transparent: its glyph and foreground
+//     color are drawn on top of the background of the cell below.
+//   - A cell with any other background fully covers the cell below.
+//
+// A glyph is visible when it is not DefaultChar (or the null character) and its foreground color is not
+// InvisibleColor.
+func Composite(layers ...*Layer) *Layer {
+	var width, height uint32
+	for _, l := range layers {
+		width = max(width, l.Width)
+		height = max(height, l.Height)
+	}
+
+	out := NewEmptyLayer(int(width), int(height))
+	if len(layers) > 0 && layers[0].ColumnMajor {
+		out.ColumnMajor = true
+	}
+
+	for i, l := range layers {
+		for y := 0; y < int(l.Height); y++ {
+			for x := 0; x < int(l.Width); x++ {
+				cell := l.At(x, y)
+				if i > 0 {
+					cell = CompositeCell(out.At(x, y), cell)
+				}
+				out.Set(x, y, cell)
+			}
+		}
+	}
+
+	return out
+}
+
+// CompositeCell returns the cell that results from drawing the cell above on top of the cell below, following
+// REXPaint's transparency rules as documented on Composite.
+func CompositeCell(below, above Cell) Cell {
+	if !above.Bg.IsInvisible() {
+		return above
+	}
+	if !above.HasVisibleGlyph() {
+		return below
+	}
+	return Cell{
+		Rune: above.Rune,
+		Fg:   above.Fg,
+		Bg:   below.Bg,
+	}
+}
@@ -0,0 +1,101 @@
+package xploader
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCompositeCell(t *testing.T) {
+	red := Color{R: 255}
+	green := Color{G: 255}
+	blue := Color{B: 255}
+	below := Cell{Rune: 'b', Fg: red, Bg: green}
+
+	tests := []struct {
+		name   string
+		above  Cell
+		expect Cell
+	}{
+		{name: "Opaque", above: Cell{Rune: 'a', Fg: blue, Bg: red}, expect: Cell{Rune: 'a', Fg: blue, Bg: red}},
+		{name: "OpaqueBlank", above: Cell{Rune: ' ', Fg: blue, Bg: red}, expect: Cell{Rune: ' ', Fg: blue, Bg: red}},
+		{name: "Empty", above: NewEmptyCell(), expect: below},
+		{name: "TransparentNull", above: Cell{Rune: 0, Fg: blue, Bg: InvisibleColor}, expect: below},
+		{name: "TransparentInvisibleGlyph", above: Cell{Rune: 'a', Fg: InvisibleColor, Bg: InvisibleColor}, expect: below},
+		{name: "PartiallyTransparent", above: Cell{Rune: 'a', Fg: blue, Bg: InvisibleColor}, expect: Cell{Rune: 'a', Fg: blue, Bg: green}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompositeCell(below, tt.above); got != tt.expect {
+				t.Errorf("Expected %+v, got %+v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestComposite(t *testing.T) {
+	bottom := NewEmptyLayer(3, 1)
+	bottom.Set(0, 0, Cell{Rune: 'a', Fg: Color{R: 1}, Bg: Color{G: 1}})
+	bottom.Set(1, 0, Cell{Rune: 'b', Fg: Color{R: 1}, Bg: Color{G: 1}})
+
+	// A smaller, column-major top layer.
+	top := &Layer{ColumnMajor: true, Width: 2, Height: 2, Cells: []Cell{NewEmptyCell(), NewEmptyCell(), NewEmptyCell(), NewEmptyCell()}}
+	top.Set(1, 0, Cell{Rune: 'B', Fg: Color{B: 1}, Bg: InvisibleColor})
+	top.Set(0, 1, Cell{Rune: 'C', Fg: Color{B: 1}, Bg: Color{R: 2}})
+
+	out := Composite(bottom, top)
+
+	if out.Width != 3 || out.Height != 2 {
+		t.Fatalf("Expected dimensions 3x2, got %dx%d", out.Width, out.Height)
+	}
+	if out.ColumnMajor {
+		t.Error("Expected result to use the layout of the bottom layer")
+	}
+
+	expected := map[[2]int]Cell{
+		{0, 0}: {Rune: 'a', Fg: Color{R: 1}, Bg: Color{G: 1}},
+		{1, 0}: {Rune: 'B', Fg: Color{B: 1}, Bg: Color{G: 1}},
+		{2, 0}: NewEmptyCell(),
+		{0, 1}: {Rune: 'C', Fg: Color{B: 1}, Bg: Color{R: 2}},
+		{1, 1}: NewEmptyCell(),
+		{2, 1}: NewEmptyCell(),
+	}
+	for pos, want := range expected {
+		if got := out.At(pos[0], pos[1]); got != want {
+			t.Errorf("Cell (%d,%d): expected %+v, got %+v", pos[0], pos[1], want, got)
+		}
+	}
+
+	if bottom.At(1, 0).Rune != 'b' {
+		t.Error("Composite modified its input")
+	}
+}
+
+func TestCompositeNoLayers(t *testing.T) {
+	out := Composite()
+	if out.Width != 0 || out.Height != 0 || len(out.Cells) != 0 {
+		t.Errorf("Expected empty layer, got %dx%d with %d cells", out.Width, out.Height, len(out.Cells))
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "multilayer.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load multilayer XP file: %v", err)
+	}
+
+	out := xp.Flatten()
+
+	base := newSimpleLayer(t)
+	for y := 0; y < int(out.Height); y++ {
+		for x := 0; x < int(out.Width); x++ {
+			want := base.At(x, y)
+			if top := xp.Layers[1].At(x, y); !top.IsEmpty() {
+				want = top
+			}
+			if got := out.At(x, y); got != want {
+				t.Errorf("Cell (%d,%d): expected %+v, got %+v", x, y, want, got)
+			}
+		}
+	}
+}
@@ -312,3 +312,13 @@ func CP437Encoder(r rune) int32 {
 	}
 	return r
 }
+
+// CP437ReplacingEncoder translates a Unicode rune to its CP437 code point like CP437Encoder, but returns the code of a
+// question mark for runes without a CP437 equivalent. Use it, for instance as SaveOptions.RuneEncoder, when the output
+// must only hold valid CP437 codes.
+func CP437ReplacingEncoder(r rune) int32 {
+	if code, ok := UnicodeToCP437[r]; ok {
+		return code
+	}
+	return '?'
+}
@@ -27,6 +27,15 @@ func TestEncoderFallback(t *testing.T) {
 	}
 }
 
+func TestCP437ReplacingEncoder(t *testing.T) {
+	if code := CP437ReplacingEncoder('☺'); code != 1 {
+		t.Errorf("Expected code 1 for ☺, got %d", code)
+	}
+	if code := CP437ReplacingEncoder(0x10FFFF); code != '?' {
+		t.Errorf("Expected a question mark for an unmapped rune, got %d", code)
+	}
+}
+
 func TestCP437DecoderFallback(t *testing.T) {
 	// Pick a value outside the CP437 range that is not in the map
 	code := int32(999)
@@ -35,3 +44,50 @@ func TestCP437DecoderFallback(t *testing.T) {
 		t.Errorf("Expected fallback to return %d as rune, got %q", code, got)
 	}
 }
+
+func FuzzCP437Decoder(f *testing.F) {
+	for _, code := range []int32{0, 1, 32, 127, 176, 254, 255, 256, -1, 0x263A} {
+		f.Add(code)
+	}
+
+	f.Fuzz(func(t *testing.T, code int32) {
+		r := CP437Decoder(code)
+
+		if code < 0 || code > 255 {
+			if r != code {
+				t.Fatalf("Expected out of range code %d to be returned as-is, got %q", code, r)
+			}
+			return
+		}
+
+		if got := CP437Encoder(r); got != code {
+			t.Fatalf("Expected code %d to round trip through %q, got %d", code, r, got)
+		}
+	})
+}
+
+func FuzzCP437Encoder(f *testing.F) {
+	for _, r := range []rune{'\u0000', ' ', 'A', '☺', '░', '\u00A0', '□', 'é', 0x10FFFF, -1} {
+		f.Add(r)
+	}
+
+	f.Fuzz(func(t *testing.T, r rune) {
+		code := CP437Encoder(r)
+
+		if _, ok := UnicodeToCP437[r]; !ok {
+			if code != r {
+				t.Fatalf("Expected unmapped rune %q to be returned as-is, got %d", r, code)
+			}
+			return
+		}
+
+		if code < 0 || code > 255 {
+			t.Fatalf("Expected rune %q to map to a CP437 code, got %d", r, code)
+		}
+
+		// NBSP shares slot 255 with the radio box glyph, so it decodes to the latter.
+		if got := CP437Decoder(code); got != r && r != '\u00A0' {
+			t.Fatalf("Expected rune %q to round trip through code %d, got %q", r, code, got)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package xploader
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvHeader is the header row of REXPaint's CSV export.
+var csvHeader = []string{"x", "y", "ascii", "fg", "bg"}
+
+// EncodeCSV writes the layer to w in REXPaint's CSV export format: a header row followed by one "x,y,ascii,fg,bg" row
+// per cell in column-major order, with CP437 codes and "#rrggbb" colors. Glyphs without a CP437 equivalent are written
+// as a question mark.
+func EncodeCSV(w io.Writer, layer *Layer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	record := make([]string, len(csvHeader))
+	for x := 0; x < int(layer.Width); x++ {
+		for y := 0; y < int(layer.Height); y++ {
+			cell := layer.At(x, y)
+			record[0] = strconv.Itoa(x)
+			record[1] = strconv.Itoa(y)
+			record[2] = strconv.Itoa(int(CP437ReplacingEncoder(cell.Rune)))
+			record[3] = cell.Fg.Hex()
+			record[4] = cell.Bg.Hex()
+
+			if err := cw.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV record: %w", err)
+			}
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return nil
+}
+
+// DecodeCSV reads a layer in REXPaint's CSV export format from r and returns it as a row-major layer. The header row
+// is optional and records may be in any order. The layer's dimensions are derived from the largest coordinates; cells
+// without a record are left empty, and layers of more than DefaultMaxCells cells are rejected. CP437 codes are converted
+// to glyphs using CP437Decoder.
+func DecodeCSV(r io.Reader) (*Layer, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	cr.TrimLeadingSpace = true
+
+	type csvCell struct {
+		x, y int
+		cell Cell
+	}
+
+	var cells []csvCell
+	width, height := 0, 0
+
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		if line == 1 && isCSVHeader(record) {
+			continue
+		}
+
+		x, y, cell, err := parseCSVRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		cells = append(cells, csvCell{x: x, y: y, cell: cell})
+		width = max(width, x+1)
+		height = max(height, y+1)
+	}
+
+	if err := checkDimensions(uint32(width), uint32(height)); err != nil {
+		return nil, err
+	}
+	// A single record with large coordinates would otherwise allocate a huge layer.
+	if err := checkCellCount(width, height); err != nil {
+		return nil, err
+	}
+
+	layer := NewEmptyLayer(width, height)
+	for _, c := range cells {
+		layer.Set(c.x, c.y, c.cell)
+	}
+
+	return layer, nil
+}
+
+// isCSVHeader returns true when the record is REXPaint's CSV header row.
+func isCSVHeader(record []string) bool {
+	for i, field := range record {
+		if !strings.EqualFold(strings.TrimSpace(field), csvHeader[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCSVRecord parses a single "x,y,ascii,fg,bg" record.
+func parseCSVRecord(record []string) (int, int, Cell, error) {
+	x, err := strconv.ParseUint(record[0], 10, 31)
+	if err != nil {
+		return 0, 0, Cell{}, fmt.Errorf("invalid x coordinate %q", record[0])
+	}
+	y, err := strconv.ParseUint(record[1], 10, 31)
+	if err != nil {
+		return 0, 0, Cell{}, fmt.Errorf("invalid y coordinate %q", record[1])
+	}
+	code, err := strconv.ParseInt(record[2], 10, 32)
+	if err != nil {
+		return 0, 0, Cell{}, fmt.Errorf("invalid ascii code %q", record[2])
+	}
+	fg, err := ParseHexColor(record[3])
+	if err != nil {
+		return 0, 0, Cell{}, fmt.Errorf("foreground: %w", err)
+	}
+	bg, err := ParseHexColor(record[4])
+	if err != nil {
+		return 0, 0, Cell{}, fmt.Errorf("background: %w", err)
+	}
+
+	ru := CP437Decoder(int32(code))
+	if ru == '\x00' {
+		ru = ' '
+	}
+
+	return int(x), int(y), Cell{Rune: ru, Fg: fg, Bg: bg}, nil
+}
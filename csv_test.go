@@ -0,0 +1,125 @@
+package xploader
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(testDataDir, "*.xp"))
+	if err != nil {
+		t.Fatalf("Failed to list test data: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			xp, err := LoadXPFile(file)
+			if err != nil {
+				t.Fatalf("Failed to load %q: %v", file, err)
+			}
+
+			for i := range xp.Layers {
+				var buf bytes.Buffer
+				if err := EncodeCSV(&buf, &xp.Layers[i]); err != nil {
+					t.Fatalf("Layer %d: failed to encode CSV: %v", i, err)
+				}
+
+				layer, err := DecodeCSV(&buf)
+				if err != nil {
+					t.Fatalf("Layer %d: failed to decode CSV: %v", i, err)
+				}
+
+				assertXPFileEqual(newXpFile(xp.Layers[i], t), newXpFile(*layer, t), t)
+			}
+		})
+	}
+}
+
+func TestCSVRoundTripUnmappableRunes(t *testing.T) {
+	// The currency sign lies below 256 but has no CP437 equivalent, the emoji lies far beyond it.
+	layer := NewEmptyLayer(3, 1)
+	layer.Set(0, 0, Cell{Rune: 'A', Fg: Color{R: 255}, Bg: Color{}})
+	layer.Set(1, 0, Cell{Rune: '¤', Fg: Color{R: 255}, Bg: Color{}})
+	layer.Set(2, 0, Cell{Rune: '😀', Fg: Color{R: 255}, Bg: Color{}})
+
+	var buf bytes.Buffer
+	if err := EncodeCSV(&buf, layer); err != nil {
+		t.Fatalf("Failed to encode CSV: %v", err)
+	}
+
+	decoded, err := DecodeCSV(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode CSV: %v", err)
+	}
+	if got, want := decoded.Text(), "A??\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeCSVFormat(t *testing.T) {
+	layer := NewEmptyLayer(2, 1)
+	layer.Set(0, 0, Cell{Rune: '░', Fg: Color{R: 255, G: 128}, Bg: Color{B: 1}})
+
+	var buf bytes.Buffer
+	if err := EncodeCSV(&buf, layer); err != nil {
+		t.Fatalf("Failed to encode CSV: %v", err)
+	}
+
+	expected := "x,y,ascii,fg,bg\n0,0,176,#ff8000,#000001\n1,0,32,#000000,#ff00ff\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	// No header, unordered records, uppercase hex colors and a missing cell.
+	doc := "1,1,65,#FFFFFF,#000000\n0,0,0,#000000,#FF00FF\n0, 1, 1, FF0000, 00FF00\n"
+
+	layer, err := DecodeCSV(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Failed to decode CSV: %v", err)
+	}
+
+	if layer.Width != 2 || layer.Height != 2 {
+		t.Fatalf("Expected dimensions 2x2, got %dx%d", layer.Width, layer.Height)
+	}
+
+	expected := map[[2]int]Cell{
+		{0, 0}: NewEmptyCell(),
+		{1, 0}: NewEmptyCell(),
+		{0, 1}: {Rune: '☺', Fg: Color{R: 255}, Bg: Color{G: 255}},
+		{1, 1}: {Rune: 'A', Fg: Color{R: 255, G: 255, B: 255}, Bg: Color{}},
+	}
+	for pos, want := range expected {
+		if got := layer.At(pos[0], pos[1]); got != want {
+			t.Errorf("Cell (%d,%d): expected %+v, got %+v", pos[0], pos[1], want, got)
+		}
+	}
+}
+
+func TestDecodeCSVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "Empty", doc: ""},
+		{name: "HeaderOnly", doc: "x,y,ascii,fg,bg\n"},
+		{name: "MissingField", doc: "0,0,65,#000000\n"},
+		{name: "NegativeX", doc: "-1,0,65,#000000,#000000\n"},
+		{name: "BadCode", doc: "0,0,A,#000000,#000000\n"},
+		{name: "BadColor", doc: "0,0,65,black,#000000\n"},
+		{name: "HugeCoordinates", doc: "2147483646,2147483646,65,#000000,#000000\n"},
+		{name: "TooManyCells", doc: "50000,50000,65,#000000,#000000\n"},
+		{name: "HeaderNotFirst", doc: "0,0,65,#000000,#000000\nx,y,ascii,fg,bg\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCSV(strings.NewReader(tt.doc)); err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package xploader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CellChange describes a cell that differs between two layers.
+type CellChange struct {
+	X, Y int
+	Old  Cell
+	New  Cell
+}
+
+// LayerDiff describes the differences between two layers at the same index.
+type LayerDiff struct {
+	Index int
+
+	OldWidth, OldHeight uint32
+	NewWidth, NewHeight uint32
+
+	// Changes lists the changed cells row by row. Cells outside a layer count as empty cells, see NewEmptyCell, so
+	// when a layer is resized only the cells painted in the area it gained or lost are listed.
+	Changes []CellChange
+}
+
+// Resized returns true when the dimensions of the layer changed.
+func (d LayerDiff) Resized() bool {
+	return d.OldWidth != d.NewWidth || d.OldHeight != d.NewHeight
+}
+
+// DiffSummary counts the differences found by Diff.
+type DiffSummary struct {
+	LayersAdded   int
+	LayersRemoved int
+	LayersResized int
+	LayersChanged int
+	CellsChanged  int
+}
+
+// String returns the summary in human readable form, e.g. "1 layer added, 12 cells changed in 2 layers".
+func (s DiffSummary) String() string {
+	var parts []string
+	if s.LayersAdded > 0 {
+		parts = append(parts, plural(s.LayersAdded, "layer")+" added")
+	}
+	if s.LayersRemoved > 0 {
+		parts = append(parts, plural(s.LayersRemoved, "layer")+" removed")
+	}
+	if s.LayersResized > 0 {
+		parts = append(parts, plural(s.LayersResized, "layer")+" resized")
+	}
+	if s.CellsChanged > 0 {
+		parts = append(parts, plural(s.CellsChanged, "cell")+" changed in "+plural(s.LayersChanged, "layer"))
+	}
+	if len(parts) == 0 {
+		return "no differences"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// plural formats n followed by the noun, adding an "s" when n is not 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// DiffResult holds the differences between two XPFiles as returned by Diff.
+type DiffResult struct {
+	// Layers lists the layers present in both files that were resized or have changed cells, by ascending index.
+	Layers []LayerDiff
+	// AddedLayers holds the indexes of the layers only present in the new file.
+	AddedLayers []int
+	// RemovedLayers holds the indexes of the layers only present in the old file.
+	RemovedLayers []int
+
+	Summary DiffSummary
+}
+
+// Equal returns true when no differences were found.
+func (d DiffResult) Equal() bool {
+	return len(d.Layers) == 0 && len(d.AddedLayers) == 0 && len(d.RemovedLayers) == 0
+}
+
+// Diff compares the old XPFile a with the new XPFile b cell by cell. Layers are matched by index: as the .xp format
+// does not name layers, a layer inserted in the middle shows up as changes to all layers after it plus an added layer
+// at the end. Metadata and the file version are not compared.
+func Diff(a, b *XPFile) DiffResult {
+	var res DiffResult
+
+	common := min(len(a.Layers), len(b.Layers))
+	for i := common; i < len(b.Layers); i++ {
+		res.AddedLayers = append(res.AddedLayers, i)
+	}
+	for i := common; i < len(a.Layers); i++ {
+		res.RemovedLayers = append(res.RemovedLayers, i)
+	}
+
+	for i := 0; i < common; i++ {
+		d := diffLayer(i, &a.Layers[i], &b.Layers[i])
+		if !d.Resized() && len(d.Changes) == 0 {
+			continue
+		}
+		res.Layers = append(res.Layers, d)
+
+		if d.Resized() {
+			res.Summary.LayersResized++
+		}
+		if len(d.Changes) > 0 {
+			res.Summary.LayersChanged++
+			res.Summary.CellsChanged += len(d.Changes)
+		}
+	}
+	res.Summary.LayersAdded = len(res.AddedLayers)
+	res.Summary.LayersRemoved = len(res.RemovedLayers)
+
+	return res
+}
+
+// diffLayer compares the cells of both layers over the union of their bounds.
+func diffLayer(index int, a, b *Layer) LayerDiff {
+	d := LayerDiff{
+		Index:     index,
+		OldWidth:  a.Width,
+		OldHeight: a.Height,
+		NewWidth:  b.Width,
+		NewHeight: b.Height,
+	}
+
+	width, height := int(max(a.Width, b.Width)), int(max(a.Height, b.Height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			old, ok := a.TryGetCell(x, y)
+			if !ok {
+				old = NewEmptyCell()
+			}
+			cur, ok := b.TryGetCell(x, y)
+			if !ok {
+				cur = NewEmptyCell()
+			}
+			if old != cur {
+				d.Changes = append(d.Changes, CellChange{X: x, Y: y, Old: old, New: cur})
+			}
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,93 @@
+package xploader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffCells(t *testing.T) {
+	a := &XPFile{Layers: []Layer{*textLayer(t, "ab\ncd"), *textLayer(t, "xy")}}
+	b := &XPFile{Layers: []Layer{*textLayer(t, "ab\ncX"), *textLayer(t, "xy")}}
+	b.Layers[0].Set(0, 0, Cell{Rune: 'a', Fg: Color{R: 255}, Bg: b.Layers[0].At(0, 0).Bg})
+
+	res := Diff(a, b)
+	want := []LayerDiff{{
+		Index: 0, OldWidth: 2, OldHeight: 2, NewWidth: 2, NewHeight: 2,
+		Changes: []CellChange{
+			{X: 0, Y: 0, Old: a.Layers[0].At(0, 0), New: b.Layers[0].At(0, 0)},
+			{X: 1, Y: 1, Old: a.Layers[0].At(1, 1), New: b.Layers[0].At(1, 1)},
+		},
+	}}
+	if !reflect.DeepEqual(res.Layers, want) {
+		t.Errorf("Got %+v, want %+v", res.Layers, want)
+	}
+	if res.Equal() {
+		t.Error("Equal() = true, want false")
+	}
+	if got, want := res.Summary.String(), "2 cells changed in 1 layer"; got != want {
+		t.Errorf("Summary: got %q, want %q", got, want)
+	}
+}
+
+func TestDiffEqual(t *testing.T) {
+	xp, err := LoadXPFile(testDataDir + "multilayer.xp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := Diff(xp, xp)
+	if !res.Equal() {
+		t.Errorf("Equal() = false: %+v", res)
+	}
+	if got, want := res.Summary.String(), "no differences"; got != want {
+		t.Errorf("Summary: got %q, want %q", got, want)
+	}
+}
+
+func TestDiffLayers(t *testing.T) {
+	a := &XPFile{Layers: []Layer{*textLayer(t, "a"), *textLayer(t, "b"), *textLayer(t, "c")}}
+	b := &XPFile{Layers: []Layer{*textLayer(t, "a")}}
+
+	res := Diff(a, b)
+	if !reflect.DeepEqual(res.RemovedLayers, []int{1, 2}) || res.AddedLayers != nil || res.Layers != nil {
+		t.Errorf("Got %+v", res)
+	}
+	if got, want := res.Summary.String(), "2 layers removed"; got != want {
+		t.Errorf("Summary: got %q, want %q", got, want)
+	}
+
+	res = Diff(b, a)
+	if !reflect.DeepEqual(res.AddedLayers, []int{1, 2}) || res.RemovedLayers != nil {
+		t.Errorf("Got %+v", res)
+	}
+	if got, want := res.Summary.String(), "2 layers added"; got != want {
+		t.Errorf("Summary: got %q, want %q", got, want)
+	}
+}
+
+func TestDiffResized(t *testing.T) {
+	a := &XPFile{Layers: []Layer{*textLayer(t, "ab")}}
+	b := &XPFile{Layers: []Layer{*a.Layers[0].Resize(3, 2, AnchorTopLeft)}}
+
+	// The area gained by resizing is empty, so no cells changed.
+	res := Diff(a, b)
+	if len(res.Layers) != 1 || !res.Layers[0].Resized() || len(res.Layers[0].Changes) != 0 {
+		t.Fatalf("Got %+v", res)
+	}
+
+	b.Layers[0].Set(2, 1, Cell{Rune: 'z'})
+	res = Diff(a, b)
+	want := []CellChange{{X: 2, Y: 1, Old: NewEmptyCell(), New: Cell{Rune: 'z'}}}
+	if !reflect.DeepEqual(res.Layers[0].Changes, want) {
+		t.Errorf("Got %+v, want %+v", res.Layers[0].Changes, want)
+	}
+	if got, want := res.Summary.String(), "1 layer resized, 1 cell changed in 1 layer"; got != want {
+		t.Errorf("Summary: got %q, want %q", got, want)
+	}
+
+	// Shrinking reports the painted cells that were cut off.
+	res = Diff(b, a)
+	want = []CellChange{{X: 2, Y: 1, Old: Cell{Rune: 'z'}, New: NewEmptyCell()}}
+	if !reflect.DeepEqual(res.Layers[0].Changes, want) {
+		t.Errorf("Got %+v, want %+v", res.Layers[0].Changes, want)
+	}
+}
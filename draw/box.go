@@ -0,0 +1,179 @@
+package draw
+
+import "github.com/malc0mn/xploder"
+
+// LineStyle selects the box drawing glyphs used for frames and box lines.
+type LineStyle int
+
+const (
+	Single LineStyle = iota
+	Double
+)
+
+// weight returns the line weight of the style.
+func (s LineStyle) weight() xploader.LineWeight {
+	if s == Double {
+		return xploader.LineDouble
+	}
+	return xploader.LineSingle
+}
+
+// Frame draws a box drawing frame around the rectangle with its top left corner at (x, y) and the given width and
+// height. Where the frame crosses or touches box drawing glyphs already on the layer, the glyphs are joined, e.g.
+// into ├, ┼ or ╬, so panels can share borders and be divided with BoxLine.
+func Frame(l *xploader.Layer, x, y, width, height int, style LineStyle, fg, bg xploader.Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	x1, y1 := x+width-1, y+height-1
+	w := style.weight()
+
+	// The sides of every border cell are worked out before joining, so the corners don't join with the edges.
+	sides := func(cx, cy int) xploader.BoxSides {
+		var s xploader.BoxSides
+		if cy == y || cy == y1 {
+			if cx > x {
+				s.Left = w
+			}
+			if cx < x1 {
+				s.Right = w
+			}
+		}
+		if cx == x || cx == x1 {
+			if cy > y {
+				s.Up = w
+			}
+			if cy < y1 {
+				s.Down = w
+			}
+		}
+		return s
+	}
+
+	// Only the edges are walked, clipped to the layer, so a huge frame costs no more than the layer's size.
+	b := l.Bounds()
+	minX, maxX := max(x, b.Min.X), min(x1, b.Max.X-1)
+	minY, maxY := max(y, b.Min.Y), min(y1, b.Max.Y-1)
+
+	for _, cy := range edges(y, y1) {
+		if cy < minY || cy > maxY {
+			continue
+		}
+		for cx := minX; cx <= maxX; cx++ {
+			joinBox(l, cx, cy, sides(cx, cy), fg, bg)
+		}
+	}
+	for _, cx := range edges(x, x1) {
+		if cx < minX || cx > maxX {
+			continue
+		}
+		// The corners were joined with the horizontal edges.
+		for cy := max(minY, y+1); cy <= min(maxY, y1-1); cy++ {
+			joinBox(l, cx, cy, sides(cx, cy), fg, bg)
+		}
+	}
+}
+
+// edges returns the coordinates of both edges of a frame along one axis, once when they coincide.
+func edges(first, last int) []int {
+	if first == last {
+		return []int{first}
+	}
+	return []int{first, last}
+}
+
+// BoxLine draws a horizontal or vertical line of box drawing glyphs from (x0, y0) to (x1, y1), joining it with box
+// drawing glyphs already on the layer. Lines that are neither horizontal nor vertical are not drawn.
+func BoxLine(l *xploader.Layer, x0, y0, x1, y1 int, style LineStyle, fg, bg xploader.Color) {
+	w := style.weight()
+
+	switch {
+	case y0 == y1:
+		x0, x1 = min(x0, x1), max(x0, x1)
+		for x := max(x0, 0); x <= min(x1, int(l.Width)-1); x++ {
+			var s xploader.BoxSides
+			if x > x0 {
+				s.Left = w
+			}
+			if x < x1 {
+				s.Right = w
+			}
+			if x0 == x1 {
+				s.Left, s.Right = w, w
+			}
+			joinBox(l, x, y0, s, fg, bg)
+		}
+	case x0 == x1:
+		y0, y1 = min(y0, y1), max(y0, y1)
+		for y := max(y0, 0); y <= min(y1, int(l.Height)-1); y++ {
+			var s xploader.BoxSides
+			if y > y0 {
+				s.Up = w
+			}
+			if y < y1 {
+				s.Down = w
+			}
+			joinBox(l, x0, y, s, fg, bg)
+		}
+	}
+}
+
+// joinBox merges the sides with those of the box drawing glyph at (x, y), if any, and draws the resulting glyph.
+func joinBox(l *xploader.Layer, x, y int, s xploader.BoxSides, fg, bg xploader.Color) {
+	cell, ok := l.TryGetCell(x, y)
+	if !ok {
+		return
+	}
+
+	if old, ok := xploader.BoxGlyphSides(cell.Rune); ok {
+		s.Up = mergeWeight(s.Up, old.Up)
+		s.Right = mergeWeight(s.Right, old.Right)
+		s.Down = mergeWeight(s.Down, old.Down)
+		s.Left = mergeWeight(s.Left, old.Left)
+	}
+
+	l.Set(x, y, xploader.Cell{Rune: boxGlyph(s), Fg: fg, Bg: bg})
+}
+
+// mergeWeight returns the line weight being drawn when it is set, the one already there otherwise.
+func mergeWeight(drawn, existing xploader.LineWeight) xploader.LineWeight {
+	if drawn != xploader.LineNone {
+		return drawn
+	}
+	return existing
+}
+
+// boxGlyph returns the box drawing glyph closest to the sides. CP437 lacks glyphs mixing weights along an axis and
+// glyphs connecting a single side, so the weights along an axis are evened out and lone sides extended first.
+func boxGlyph(s xploader.BoxSides) rune {
+	if r, ok := xploader.BoxGlyph(s); ok {
+		return r
+	}
+
+	v, h := max(s.Up, s.Down), max(s.Left, s.Right)
+	if s.Up != xploader.LineNone {
+		s.Up = v
+	}
+	if s.Down != xploader.LineNone {
+		s.Down = v
+	}
+	if s.Left != xploader.LineNone {
+		s.Left = h
+	}
+	if s.Right != xploader.LineNone {
+		s.Right = h
+	}
+	if r, ok := xploader.BoxGlyph(s); ok {
+		return r
+	}
+
+	if h == xploader.LineNone {
+		s.Up, s.Down = v, v
+	} else if v == xploader.LineNone {
+		s.Left, s.Right = h, h
+	}
+	if r, ok := xploader.BoxGlyph(s); ok {
+		return r
+	}
+	return ' '
+}
@@ -0,0 +1,103 @@
+package draw
+
+import (
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+func TestFrame(t *testing.T) {
+	l := xploader.NewEmptyLayer(6, 4)
+	Frame(l, 0, 0, 5, 3, Single, xploader.Color{}, xploader.Color{})
+	want := "" +
+		"┌───┐\n" +
+		"│   │\n" +
+		"└───┘\n" +
+		"\n"
+	if got := l.Text(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFrameJoins(t *testing.T) {
+	l := xploader.NewEmptyLayer(7, 5)
+	Frame(l, 0, 0, 7, 5, Double, xploader.Color{}, xploader.Color{})
+	BoxLine(l, 3, 0, 3, 4, Single, xploader.Color{}, xploader.Color{})
+	BoxLine(l, 0, 2, 6, 2, Double, xploader.Color{}, xploader.Color{})
+	want := "" +
+		"╔══╤══╗\n" +
+		"║  │  ║\n" +
+		"╠══╪══╣\n" +
+		"║  │  ║\n" +
+		"╚══╧══╝\n"
+	if got := l.Text(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFrameOverlap(t *testing.T) {
+	l := xploader.NewEmptyLayer(6, 4)
+	Frame(l, 0, 0, 4, 3, Single, xploader.Color{}, xploader.Color{})
+	Frame(l, 2, 1, 4, 3, Single, xploader.Color{}, xploader.Color{})
+	want := "" +
+		"┌──┐\n" +
+		"│ ┌┼─┐\n" +
+		"└─┼┘ │\n" +
+		"  └──┘\n"
+	if got := l.Text(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFrameClipped(t *testing.T) {
+	// Frames far larger than the layer only cost as much as their visible edges.
+	l := xploader.NewEmptyLayer(4, 3)
+	Frame(l, 1, 1, 1e9, 1e9, Single, xploader.Color{}, xploader.Color{})
+	want := "" +
+		"\n" +
+		" ┌──\n" +
+		" │\n"
+	if got := l.Text(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+
+	l = xploader.NewEmptyLayer(4, 3)
+	Frame(l, -1e9, -1e9, 1e9+2, 1e9+2, Double, xploader.Color{}, xploader.Color{})
+	want = "" +
+		" ║\n" +
+		"═╝\n" +
+		"\n"
+	if got := l.Text(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBoxLineClipped(t *testing.T) {
+	l := xploader.NewEmptyLayer(4, 3)
+	BoxLine(l, -1e9, 1, 1e9, 1, Single, xploader.Color{}, xploader.Color{})
+	BoxLine(l, 2, 1e9, 2, 1, Double, xploader.Color{}, xploader.Color{})
+	want := "" +
+		"\n" +
+		"──╥─\n" +
+		"  ║\n"
+	if got := l.Text(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBoxGlyphFallback(t *testing.T) {
+	tests := []struct {
+		sides xploader.BoxSides
+		want  rune
+	}{
+		{xploader.BoxSides{Up: 1, Right: 2, Down: 2}, '╠'},
+		{xploader.BoxSides{Right: 1}, '─'},
+		{xploader.BoxSides{Up: 2}, '║'},
+		{xploader.BoxSides{}, ' '},
+	}
+	for _, tt := range tests {
+		if got := boxGlyph(tt.sides); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.sides, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package draw provides drawing primitives operating on layers: lines, rectangles, ellipses, box drawing frames and
+// text. Everything drawn is clipped to the layer's bounds.
+package draw
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/malc0mn/xploder"
+)
+
+// Line draws a line from (x0, y0) to (x1, y1), both ends included, plotting the same cells as Bresenham's algorithm.
+func Line(l *xploader.Layer, x0, y0, x1, y1 int, cell xploader.Cell) {
+	// Walk along the major axis, on which the line advances every step, and work out the minor coordinate of each step
+	// directly, so only the steps within the layer are visited however long the line is.
+	steep := abs(y1-y0) > abs(x1-x0)
+	maj0, min0, dmaj, dmin, size := x0, y0, x1-x0, y1-y0, int(l.Width)
+	if steep {
+		maj0, min0, dmaj, dmin, size = y0, x0, y1-y0, x1-x0, int(l.Height)
+	}
+	steps, slope := abs(dmaj), abs(dmin)
+	smaj, smin := sign(dmaj), sign(dmin)
+	if smaj == 0 {
+		smaj = 1
+	}
+
+	// The steps k for which 0 <= maj0 + k*smaj < size.
+	first, last := -maj0, size-1-maj0
+	if smaj < 0 {
+		first, last = maj0-(size-1), maj0
+	}
+	for k := max(first, 0); k <= min(last, steps); k++ {
+		x, y := maj0+k*smaj, min0+minorOffset(k, steps, slope)*smin
+		if steep {
+			x, y = y, x
+		}
+		l.SetCell(x, y, cell)
+	}
+}
+
+// minorOffset returns how far a Bresenham line that advances slope cells along its minor axis over steps cells along
+// its major axis has advanced along the minor axis after k steps: k*slope/steps rounded half up. The intermediate
+// product is computed in 128 bits so huge lines don't overflow.
+func minorOffset(k, steps, slope int) int {
+	if steps == 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(uint64(2*slope), uint64(k))
+	lo, carry := bits.Add64(lo, uint64(steps), 0)
+	q, _ := bits.Div64(hi+carry, lo, uint64(2*steps))
+	return int(q)
+}
+
+// Rect draws the outline of the rectangle with its top left corner at (x, y) and the given width and height.
+func Rect(l *xploader.Layer, x, y, width, height int, cell xploader.Cell) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	l.FillRect(x, y, width, 1, cell)
+	l.FillRect(x, y+height-1, width, 1, cell)
+	l.FillRect(x, y, 1, height, cell)
+	l.FillRect(x+width-1, y, 1, height, cell)
+}
+
+// FillRect fills the rectangle with its top left corner at (x, y) and the given width and height.
+func FillRect(l *xploader.Layer, x, y, width, height int, cell xploader.Cell) {
+	l.FillRect(x, y, width, height, cell)
+}
+
+// Circle draws the outline of the circle centered at (cx, cy) with radius r.
+func Circle(l *xploader.Layer, cx, cy, r int, cell xploader.Cell) {
+	Ellipse(l, cx, cy, r, r, cell)
+}
+
+// FillCircle fills the circle centered at (cx, cy) with radius r.
+func FillCircle(l *xploader.Layer, cx, cy, r int, cell xploader.Cell) {
+	FillEllipse(l, cx, cy, r, r, cell)
+}
+
+// Ellipse draws the outline of the ellipse centered at (cx, cy) with horizontal radius rx and vertical radius ry. As
+// cells are usually taller than wide, an rx of about twice ry looks round with most fonts.
+func Ellipse(l *xploader.Layer, cx, cy, rx, ry int, cell xploader.Cell) {
+	rx, ry = max(rx, 0), max(ry, 0)
+
+	// Plotting the edge for every row and for every column leaves no gaps on the steep and the flat parts. Only the
+	// rows and columns within the layer are visited.
+	for dy := max(-ry, -cy); dy <= min(ry, int(l.Height)-1-cy); dy++ {
+		dx := ellipseSpan(rx, ry, dy)
+		l.SetCell(cx-dx, cy+dy, cell)
+		l.SetCell(cx+dx, cy+dy, cell)
+	}
+	for dx := max(-rx, -cx); dx <= min(rx, int(l.Width)-1-cx); dx++ {
+		dy := ellipseSpan(ry, rx, dx)
+		l.SetCell(cx+dx, cy-dy, cell)
+		l.SetCell(cx+dx, cy+dy, cell)
+	}
+}
+
+// FillEllipse fills the ellipse centered at (cx, cy) with horizontal radius rx and vertical radius ry.
+func FillEllipse(l *xploader.Layer, cx, cy, rx, ry int, cell xploader.Cell) {
+	rx, ry = max(rx, 0), max(ry, 0)
+
+	for dy := max(-ry, -cy); dy <= min(ry, int(l.Height)-1-cy); dy++ {
+		dx := ellipseSpan(rx, ry, dy)
+		l.FillRect(cx-dx, cy+dy, 2*dx+1, 1, cell)
+	}
+}
+
+// ellipseSpan returns the distance from the center to the edge of an ellipse with radii a and b, along the a axis, at
+// distance d from the center along the b axis.
+func ellipseSpan(a, b, d int) int {
+	// Growing the radii by half a cell gives rounder shapes than the exact ones, which come to a point at the ends.
+	f := float64(d) / (float64(b) + 0.5)
+	return int((float64(a) + 0.5) * math.Sqrt(max(1-f*f, 0)))
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// sign returns -1, 0 or 1 depending on the sign of n.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
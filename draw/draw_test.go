@@ -0,0 +1,158 @@
+package draw
+
+import (
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+var hash = xploader.Cell{Rune: '#'}
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 int
+		want           string
+	}{
+		{"horizontal", 0, 1, 4, 1, "\n#####\n\n"},
+		{"vertical", 2, 2, 2, 0, "  #\n  #\n  #\n"},
+		{"diagonal", 0, 0, 2, 2, "#\n #\n  #\n"},
+		{"shallow", 0, 0, 4, 2, "#\n ##\n   ##\n"},
+		{"clipped", -2, 0, 6, 0, "#####\n\n\n"},
+		{"point", 1, 1, 1, 1, "\n #\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := xploader.NewEmptyLayer(5, 3)
+			Line(l, tt.x0, tt.y0, tt.x1, tt.y1, hash)
+			if got := l.Text(); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// bresenham is the classic, unclipped line algorithm Line must match.
+func bresenham(l *xploader.Layer, x0, y0, x1, y1 int, cell xploader.Cell) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+
+	for {
+		l.SetCell(x0, y0, cell)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func TestLineMatchesBresenham(t *testing.T) {
+	// Every line between two points around a small layer, so clipping on all sides and in all directions is covered.
+	for x0 := -3; x0 <= 7; x0++ {
+		for y0 := -3; y0 <= 6; y0++ {
+			for x1 := -9; x1 <= 13; x1 += 2 {
+				for y1 := -9; y1 <= 12; y1 += 3 {
+					got, want := xploader.NewEmptyLayer(5, 4), xploader.NewEmptyLayer(5, 4)
+					Line(got, x0, y0, x1, y1, hash)
+					bresenham(want, x0, y0, x1, y1, hash)
+					if got.Text() != want.Text() {
+						t.Fatalf("Line(%d, %d, %d, %d): got %q, want %q", x0, y0, x1, y1, got.Text(), want.Text())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestHugeShapes(t *testing.T) {
+	// Shapes far larger than the layer only cost as much as the part within it.
+	l := xploader.NewEmptyLayer(5, 3)
+	Line(l, 0, 0, 1<<40, 0, hash)
+	Line(l, -1<<40, 2, 1<<40, 2, hash)
+	if got, want := l.Text(), "#####\n\n#####\n"; got != want {
+		t.Errorf("Line: got %q, want %q", got, want)
+	}
+
+	l = xploader.NewEmptyLayer(5, 3)
+	Line(l, 0, 0, 1<<40, 1<<39, hash)
+	if got, want := l.Text(), "#\n ##\n   ##\n"; got != want {
+		t.Errorf("Sloped line: got %q, want %q", got, want)
+	}
+
+	l = xploader.NewEmptyLayer(5, 3)
+	Ellipse(l, 2, 1, 1<<40, 1, hash)
+	if got, want := l.Text(), "#####\n\n#####\n"; got != want {
+		t.Errorf("Ellipse: got %q, want %q", got, want)
+	}
+
+	l = xploader.NewEmptyLayer(5, 3)
+	FillEllipse(l, 2, 1, 1<<40, 1<<40, hash)
+	if got, want := l.Text(), "#####\n#####\n#####\n"; got != want {
+		t.Errorf("FillEllipse: got %q, want %q", got, want)
+	}
+}
+
+func TestRect(t *testing.T) {
+	l := xploader.NewEmptyLayer(5, 4)
+	Rect(l, 0, 0, 4, 3, hash)
+	if got, want := l.Text(), "####\n#  #\n####\n\n"; got != want {
+		t.Errorf("Rect: got %q, want %q", got, want)
+	}
+
+	l = xploader.NewEmptyLayer(5, 4)
+	FillRect(l, 1, 1, 9, 2, hash)
+	if got, want := l.Text(), "\n ####\n ####\n\n"; got != want {
+		t.Errorf("FillRect: got %q, want %q", got, want)
+	}
+}
+
+func TestEllipse(t *testing.T) {
+	l := xploader.NewEmptyLayer(7, 5)
+	Ellipse(l, 3, 2, 3, 2, hash)
+	want := "" +
+		" #####\n" +
+		"#     #\n" +
+		"#     #\n" +
+		"#     #\n" +
+		" #####\n"
+	if got := l.Text(); got != want {
+		t.Errorf("Ellipse: got\n%s\nwant\n%s", got, want)
+	}
+
+	l = xploader.NewEmptyLayer(7, 5)
+	FillEllipse(l, 3, 2, 3, 2, hash)
+	want = "" +
+		" #####\n" +
+		"#######\n" +
+		"#######\n" +
+		"#######\n" +
+		" #####\n"
+	if got := l.Text(); got != want {
+		t.Errorf("FillEllipse: got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCircle(t *testing.T) {
+	l := xploader.NewEmptyLayer(5, 5)
+	Circle(l, 2, 2, 0, hash)
+	if got, want := l.Text(), "\n\n  #\n\n\n"; got != want {
+		t.Errorf("Zero radius: got %q, want %q", got, want)
+	}
+
+	l = xploader.NewEmptyLayer(5, 5)
+	FillCircle(l, 2, 2, 2, hash)
+	want := " ###\n#####\n#####\n#####\n ###\n"
+	if got := l.Text(); got != want {
+		t.Errorf("FillCircle: got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package draw
+
+import (
+	"strings"
+
+	"github.com/malc0mn/xploder"
+)
+
+// Align selects the horizontal alignment of text lines.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// TextOptions controls how Text lays out text.
+type TextOptions struct {
+	// Width is the width of the text box in cells, lines are aligned within it. Defaults to the distance from the
+	// starting column to the right edge of the layer.
+	Width int
+
+	// Height is the maximum number of lines. Defaults to the distance from the starting row to the bottom edge of the
+	// layer.
+	Height int
+
+	// Wrap breaks lines longer than Width at spaces, or within words that don't fit on a line of their own. Otherwise
+	// long lines are clipped.
+	Wrap bool
+
+	// Align is the alignment of the lines within Width.
+	Align Align
+}
+
+// Text writes s in the text box with its top left corner at (x, y), using the given colors, and returns the number
+// of lines written. Lines are separated by "\n" and can be wrapped and aligned, see TextOptions. Text that does not fit
+// the text box or the layer is clipped.
+func Text(l *xploader.Layer, x, y int, s string, fg, bg xploader.Color, opts TextOptions) int {
+	width, height := opts.Width, opts.Height
+	if width <= 0 {
+		width = int(l.Width) - x
+	}
+	if height <= 0 {
+		height = int(l.Height) - y
+	}
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if opts.Wrap {
+			lines = append(lines, wrap(line, width)...)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	lines = lines[:min(len(lines), height)]
+
+	for i, line := range lines {
+		runes := []rune(line)
+		if len(runes) > width {
+			runes = runes[:width]
+		}
+
+		ox := 0
+		switch opts.Align {
+		case AlignCenter:
+			ox = (width - len(runes)) / 2
+		case AlignRight:
+			ox = width - len(runes)
+		}
+
+		for j, r := range runes {
+			l.SetCell(x+ox+j, y+i, xploader.Cell{Rune: r, Fg: fg, Bg: bg})
+		}
+	}
+
+	return len(lines)
+}
+
+// wrap breaks a line into lines of at most width runes, at spaces where possible.
+func wrap(line string, width int) []string {
+	var lines []string
+	var cur []rune
+
+	for _, word := range strings.Fields(line) {
+		w := []rune(word)
+		if len(cur) > 0 && len(cur)+1+len(w) <= width {
+			cur = append(append(cur, ' '), w...)
+			continue
+		}
+		if len(cur) > 0 {
+			lines = append(lines, string(cur))
+			cur = nil
+		}
+		for len(w) > width {
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		cur = w
+	}
+
+	if len(cur) > 0 || len(lines) == 0 {
+		lines = append(lines, string(cur))
+	}
+	return lines
+}
@@ -0,0 +1,40 @@
+package draw
+
+import (
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name  string
+		x, y  int
+		s     string
+		opts  TextOptions
+		want  string
+		lines int
+	}{
+		{"plain", 1, 0, "ab\ncd", TextOptions{}, " ab\n cd\n\n", 2},
+		{"clipped", 4, 0, "abcdef", TextOptions{}, "    ab\n\n\n", 1},
+		{"wrap", 0, 0, "the quick fox", TextOptions{Width: 5, Wrap: true}, "the\nquick\nfox\n", 3},
+		{"wrap long word", 0, 0, "abcdefgh", TextOptions{Width: 3, Wrap: true}, "abc\ndef\ngh\n", 3},
+		{"height", 0, 0, "a\nb\nc", TextOptions{Height: 2}, "a\nb\n\n", 2},
+		{"center", 0, 0, "ab", TextOptions{Width: 6, Align: AlignCenter}, "  ab\n\n\n", 1},
+		{"right", 1, 1, "ab", TextOptions{Align: AlignRight}, "\n    ab\n\n", 1},
+		{"outside", 0, 5, "ab", TextOptions{}, "\n\n\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := xploader.NewEmptyLayer(6, 3)
+			lines := Text(l, tt.x, tt.y, tt.s, xploader.Color{R: 255}, xploader.Color{}, tt.opts)
+			if got := l.Text(); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+			if lines != tt.lines {
+				t.Errorf("Got %d lines, want %d", lines, tt.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package xploader
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Sentinel errors describing why parsing failed. A *ParseError matches exactly one of these through errors.Is,
+// depending on its Kind.
+var (
+	// ErrTruncated is reported when the data ends before the XP stream is complete.
+	ErrTruncated = errors.New("truncated data")
+
+	// ErrCorruptHeader is reported when the gzip header of a compressed file is invalid.
+	ErrCorruptHeader = errors.New("corrupt header")
+
+	// ErrCorruptData is reported when the compressed stream is damaged, e.g. on a checksum mismatch.
+	ErrCorruptData = errors.New("corrupt data")
+
+	// ErrInvalidDimensions is reported when a layer header contains dimensions no valid XP file can have.
+	ErrInvalidDimensions = errors.New("invalid dimensions")
+
+	// ErrLimitExceeded is reported when the data exceeds one of the resource limits set in LoadOptions.
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// ParseErrorKind classifies a ParseError.
+type ParseErrorKind int
+
+const (
+	// KindIO indicates that the underlying reader returned an error unrelated to the XP data itself.
+	KindIO ParseErrorKind = iota
+	// KindTruncated matches ErrTruncated.
+	KindTruncated
+	// KindCorruptHeader matches ErrCorruptHeader.
+	KindCorruptHeader
+	// KindCorruptData matches ErrCorruptData.
+	KindCorruptData
+	// KindInvalidDimensions matches ErrInvalidDimensions.
+	KindInvalidDimensions
+	// KindLimitExceeded matches ErrLimitExceeded.
+	KindLimitExceeded
+)
+
+// String returns a human-readable name for the kind.
+func (k ParseErrorKind) String() string {
+	switch k {
+	case KindIO:
+		return "i/o error"
+	case KindTruncated:
+		return ErrTruncated.Error()
+	case KindCorruptHeader:
+		return ErrCorruptHeader.Error()
+	case KindCorruptData:
+		return ErrCorruptData.Error()
+	case KindInvalidDimensions:
+		return ErrInvalidDimensions.Error()
+	case KindLimitExceeded:
+		return ErrLimitExceeded.Error()
+	}
+	return fmt.Sprintf("ParseErrorKind(%d)", int(k))
+}
+
+// sentinel returns the sentinel error matching the kind, or nil when there is none.
+func (k ParseErrorKind) sentinel() error {
+	switch k {
+	case KindTruncated:
+		return ErrTruncated
+	case KindCorruptHeader:
+		return ErrCorruptHeader
+	case KindCorruptData:
+		return ErrCorruptData
+	case KindInvalidDimensions:
+		return ErrInvalidDimensions
+	case KindLimitExceeded:
+		return ErrLimitExceeded
+	}
+	return nil
+}
+
+// ParseError describes a failure to parse an XP stream, pinpointing where in the stream it occurred.
+type ParseError struct {
+	// Kind classifies the error.
+	Kind ParseErrorKind
+
+	// Field names the value that was being read, e.g. "version", "layer width" or "codepoint".
+	Field string
+
+	// Layer is the zero based index of the layer being read, or -1 when reading the file header.
+	Layer int
+
+	// X and Y are the logical coordinates of the cell being read, or -1 when not reading a cell.
+	X, Y int
+
+	// Offset is the byte offset of Field in the uncompressed XP stream.
+	Offset int64
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	var b strings.Builder
+
+	b.WriteString("xploader: ")
+	b.WriteString(e.Kind.String())
+	if e.Field != "" {
+		fmt.Fprintf(&b, " reading %s", e.Field)
+	}
+	if e.Layer >= 0 {
+		fmt.Fprintf(&b, " of layer %d", e.Layer)
+	}
+	if e.X >= 0 && e.Y >= 0 {
+		fmt.Fprintf(&b, " at cell (%d,%d)", e.X, e.Y)
+	}
+	fmt.Fprintf(&b, ", offset %d", e.Offset)
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error matching the error's Kind.
+func (e *ParseError) Is(target error) bool {
+	s := e.Kind.sentinel()
+	return s != nil && s == target
+}
+
+// newParseError creates a ParseError for a header field, classifying err to determine its Kind.
+func newParseError(field string, layer int, offset int64, err error) *ParseError {
+	return &ParseError{
+		Kind:   classifyReadError(err),
+		Field:  field,
+		Layer:  layer,
+		X:      -1,
+		Y:      -1,
+		Offset: offset,
+		Err:    err,
+	}
+}
+
+// newCellParseError creates a ParseError for a field of the cell at (x, y), classifying err to determine its Kind.
+func newCellParseError(field string, layer, x, y int, offset int64, err error) *ParseError {
+	e := newParseError(field, layer, offset, err)
+	e.X = x
+	e.Y = y
+	return e
+}
+
+// newLimitError creates a ParseError of kind KindLimitExceeded for a header field.
+func newLimitError(field string, layer int, offset int64, format string, args ...any) *ParseError {
+	e := newParseError(field, layer, offset, fmt.Errorf(format, args...))
+	e.Kind = KindLimitExceeded
+	return e
+}
+
+// classifyReadError maps an error returned while reading to a ParseErrorKind.
+func classifyReadError(err error) ParseErrorKind {
+	var corrupt flate.CorruptInputError
+	var limit *streamLimitError
+
+	switch {
+	case errors.As(err, &limit):
+		return KindLimitExceeded
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return KindTruncated
+	case errors.Is(err, gzip.ErrHeader):
+		return KindCorruptHeader
+	case errors.Is(err, gzip.ErrChecksum), errors.As(err, &corrupt):
+		return KindCorruptData
+	}
+	return KindIO
+}
+
+// streamLimitError is returned by offsetReader when the stream exceeds LoadOptions.MaxDecompressedBytes.
+type streamLimitError struct {
+	max int64
+}
+
+// Error implements the error interface.
+func (e *streamLimitError) Error() string {
+	return fmt.Sprintf("stream exceeds MaxDecompressedBytes of %d", e.max)
+}
+
+// offsetReader counts the bytes read through it so errors can report their position in the stream. When max is
+// positive, reading beyond max bytes fails with a *streamLimitError.
+type offsetReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+// newOffsetReader wraps r in an offsetReader enforcing the LoadOptions.MaxDecompressedBytes limit.
+func newOffsetReader(r io.Reader, opts LoadOptions) *offsetReader {
+	return &offsetReader{r: r, max: opts.MaxDecompressedBytes}
+}
+
+// Read implements io.Reader.
+func (o *offsetReader) Read(p []byte) (int, error) {
+	if o.max <= 0 {
+		n, err := o.r.Read(p)
+		o.n += int64(n)
+		return n, err
+	}
+
+	if o.n >= o.max {
+		// Probe for a single byte to tell a stream ending exactly at the limit from one exceeding it.
+		var probe [1]byte
+		if n, err := o.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, &streamLimitError{max: o.max}
+	}
+
+	if rem := o.max - o.n; int64(len(p)) > rem {
+		p = p[:rem]
+	}
+	n, err := o.r.Read(p)
+	o.n += int64(n)
+	return n, err
+}
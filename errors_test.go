@@ -0,0 +1,178 @@
+package xploader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readTestFile(name string, t *testing.T) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(testDataDir, name))
+	if err != nil {
+		t.Fatalf("Failed to read %q: %v", name, err)
+	}
+	return data
+}
+
+func assertParseError(err error, kind ParseErrorKind, t *testing.T) *ParseError {
+	t.Helper()
+
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *ParseError, got %T: %v", err, err)
+	}
+	if pe.Kind != kind {
+		t.Fatalf("Expected kind %q, got %q: %v", kind, pe.Kind, err)
+	}
+	return pe
+}
+
+func TestParseErrorTruncated(t *testing.T) {
+	data := readTestFile("simple_plain.xp", t)
+
+	tests := []struct {
+		name   string
+		length int
+		field  string
+		layer  int
+		x, y   int
+		offset int64
+	}{
+		{name: "Empty", length: 0, field: "gzip magic", layer: -1, x: -1, y: -1, offset: 0},
+		{name: "Version", length: 3, field: "version", layer: -1, x: -1, y: -1, offset: 0},
+		{name: "LayerCount", length: 6, field: "layer count", layer: -1, x: -1, y: -1, offset: 4},
+		{name: "LayerWidth", length: 8, field: "layer width", layer: 0, x: -1, y: -1, offset: 8},
+		{name: "LayerHeight", length: 13, field: "layer height", layer: 0, x: -1, y: -1, offset: 12},
+		{name: "Codepoint", length: 16, field: "codepoint", layer: 0, x: 0, y: 0, offset: 16},
+		{name: "Foreground", length: 21, field: "foreground color", layer: 0, x: 0, y: 0, offset: 20},
+		{name: "Background", length: 23, field: "background color", layer: 0, x: 0, y: 0, offset: 23},
+		{name: "SecondCell", length: 28, field: "codepoint", layer: 0, x: 0, y: 1, offset: 26},
+		{name: "LastCell", length: len(data) - 1, field: "background color", layer: 0, x: 9, y: 14, offset: int64(len(data) - 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadXPFromReader(bytes.NewReader(data[:tt.length]), LoadOptions{})
+
+			pe := assertParseError(err, KindTruncated, t)
+			if !errors.Is(err, ErrTruncated) {
+				t.Error("Expected errors.Is(err, ErrTruncated)")
+			}
+			if errors.Is(err, ErrCorruptHeader) || errors.Is(err, ErrInvalidDimensions) {
+				t.Error("Expected error to match ErrTruncated only")
+			}
+			if pe.Field != tt.field {
+				t.Errorf("Expected field %q, got %q", tt.field, pe.Field)
+			}
+			if pe.Layer != tt.layer {
+				t.Errorf("Expected layer %d, got %d", tt.layer, pe.Layer)
+			}
+			if pe.X != tt.x || pe.Y != tt.y {
+				t.Errorf("Expected cell (%d,%d), got (%d,%d)", tt.x, tt.y, pe.X, pe.Y)
+			}
+			if pe.Offset != tt.offset {
+				t.Errorf("Expected offset %d, got %d", tt.offset, pe.Offset)
+			}
+		})
+	}
+}
+
+func TestParseErrorTruncatedGzip(t *testing.T) {
+	data := readTestFile("simple.xp", t)
+
+	_, err := LoadXPFromReader(bytes.NewReader(data[:len(data)/2]), LoadOptions{})
+	assertParseError(err, KindTruncated, t)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("Expected underlying io.ErrUnexpectedEOF to be preserved")
+	}
+}
+
+func TestParseErrorCorruptGzipHeader(t *testing.T) {
+	data := []byte{gzipID1, gzipID2, 0xFF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	_, err := LoadXPFromReader(bytes.NewReader(data), LoadOptions{})
+	pe := assertParseError(err, KindCorruptHeader, t)
+	if !errors.Is(err, ErrCorruptHeader) {
+		t.Error("Expected errors.Is(err, ErrCorruptHeader)")
+	}
+	if pe.Field != "gzip header" {
+		t.Errorf("Expected field %q, got %q", "gzip header", pe.Field)
+	}
+}
+
+func TestParseErrorCorruptGzipData(t *testing.T) {
+	data := readTestFile("simple.xp", t)
+
+	// Damage the CRC-32 in the gzip trailer.
+	corrupt := bytes.Clone(data)
+	corrupt[len(corrupt)-8] ^= 0xFF
+
+	_, err := LoadXPFromReader(bytes.NewReader(corrupt), LoadOptions{})
+	assertParseError(err, KindCorruptData, t)
+	if !errors.Is(err, ErrCorruptData) {
+		t.Error("Expected errors.Is(err, ErrCorruptData)")
+	}
+}
+
+func TestParseErrorInvalidDimensions(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height uint32
+	}{
+		{name: "ZeroWidth", width: 0, height: 10},
+		{name: "ZeroHeight", width: 10, height: 0},
+		{name: "TooWide", width: 1 << 31, height: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			for _, v := range []any{int32(-1), uint32(1), tt.width, tt.height} {
+				_ = binary.Write(&buf, binary.LittleEndian, v)
+			}
+
+			_, err := LoadXPFromReader(&buf, LoadOptions{})
+			pe := assertParseError(err, KindInvalidDimensions, t)
+			if !errors.Is(err, ErrInvalidDimensions) {
+				t.Error("Expected errors.Is(err, ErrInvalidDimensions)")
+			}
+			if pe.Layer != 0 || pe.Offset != 8 {
+				t.Errorf("Expected layer 0 at offset 8, got layer %d at offset %d", pe.Layer, pe.Offset)
+			}
+		})
+	}
+}
+
+func TestParseErrorIO(t *testing.T) {
+	failure := errors.New("disk on fire")
+	r := io.MultiReader(bytes.NewReader([]byte{0xFF, 0xFF}), &failingReader{err: failure})
+
+	_, err := LoadXPFromReader(r, LoadOptions{})
+	assertParseError(err, KindIO, t)
+	if !errors.Is(err, failure) {
+		t.Error("Expected underlying error to be preserved")
+	}
+	for _, sentinel := range []error{ErrTruncated, ErrCorruptHeader, ErrCorruptData, ErrInvalidDimensions} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("Expected I/O error not to match %v", sentinel)
+		}
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (f *failingReader) Read([]byte) (int, error) {
+	return 0, f.err
+}
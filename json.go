@@ -0,0 +1,229 @@
+package xploader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// The JSON representation of the types in this package is stable and lossless: an XPFile encoded to JSON and decoded
+// again marshals to exactly the same .xp bytes. Its schema is:
+//
+//	XPFile: {"version": -1, "meta": FileMeta, "layers": [Layer, ...]}
+//	Layer:  {"width": 80, "height": 25, "meta": LayerMeta, "cells": [[Cell, ...], ...]}
+//	Cell:   {"glyph": "☺", "code": 1, "fg": "#ffffff", "bg": "#ff00ff"}
+//	Color:  "#rrggbb"
+//
+// Layer cells are always listed row by row, top to bottom, regardless of the layer's memory layout. Decoded layers are
+// row-major. The "meta" keys are omitted when there is no metadata.
+//
+// The "glyph" of a cell is its rune as a string and "code" is the CP437 code point that rune maps to in REXPaint's
+// default font, see CP437Encoder. When decoding, the glyph takes precedence. The code is only used when the glyph is
+// omitted, which is also how runes that are not valid Unicode are encoded.
+
+// jsonCell is the JSON representation of a Cell.
+type jsonCell struct {
+	Glyph *string `json:"glyph,omitempty"`
+	Code  int32   `json:"code"`
+	Fg    Color   `json:"fg"`
+	Bg    Color   `json:"bg"`
+}
+
+// jsonLayer is the JSON representation of a Layer.
+type jsonLayer struct {
+	Width  uint32     `json:"width"`
+	Height uint32     `json:"height"`
+	Meta   *LayerMeta `json:"meta,omitempty"`
+	Cells  [][]Cell   `json:"cells"`
+}
+
+// jsonXPFile is the JSON representation of an XPFile.
+type jsonXPFile struct {
+	Version int32     `json:"version"`
+	Meta    *FileMeta `json:"meta,omitempty"`
+	Layers  []Layer   `json:"layers"`
+}
+
+// marshalJSON encodes v like json.Marshal, but without escaping HTML characters. Whether they get escaped is left to
+// the caller's json.Marshal call or json.Encoder configuration.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding the color as "#rrggbb".
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Hex())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a color in "#rrggbb" notation.
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("color must be a string: %w", err)
+	}
+
+	color, err := ParseHexColor(s)
+	if err != nil {
+		return err
+	}
+	*c = color
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Cell) MarshalJSON() ([]byte, error) {
+	jc := jsonCell{
+		Code: CP437Encoder(c.Rune),
+		Fg:   c.Fg,
+		Bg:   c.Bg,
+	}
+	if utf8.ValidRune(c.Rune) {
+		glyph := string(c.Rune)
+		jc.Glyph = &glyph
+	}
+
+	return marshalJSON(jc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Cell) UnmarshalJSON(data []byte) error {
+	var jc jsonCell
+	if err := json.Unmarshal(data, &jc); err != nil {
+		return err
+	}
+
+	r := CP437Decoder(jc.Code)
+	if jc.Glyph != nil {
+		var size int
+		r, size = utf8.DecodeRuneInString(*jc.Glyph)
+		if size == 0 || size != len(*jc.Glyph) {
+			return fmt.Errorf("glyph %q must be a single rune", *jc.Glyph)
+		}
+	}
+
+	*c = Cell{Rune: r, Fg: jc.Fg, Bg: jc.Bg}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l Layer) MarshalJSON() ([]byte, error) {
+	if err := l.checkCells(); err != nil {
+		return nil, err
+	}
+
+	jl := jsonLayer{
+		Width:  l.Width,
+		Height: l.Height,
+		Meta:   l.Meta,
+		Cells:  make([][]Cell, l.Height),
+	}
+	for y := range jl.Cells {
+		jl.Cells[y] = l.Row(y)
+	}
+
+	return marshalJSON(jl)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The decoded layer is row-major. Layers of more than DefaultMaxCells cells
+// are rejected.
+func (l *Layer) UnmarshalJSON(data []byte) error {
+	var jl jsonLayer
+	if err := json.Unmarshal(data, &jl); err != nil {
+		return err
+	}
+
+	if err := checkDimensions(jl.Width, jl.Height); err != nil {
+		return err
+	}
+	if uint64(len(jl.Cells)) != uint64(jl.Height) {
+		return fmt.Errorf("layer of %dx%d cells has %d rows, expected %d", jl.Width, jl.Height, len(jl.Cells), jl.Height)
+	}
+	// Check the declared width against the cell data before allocating anything based on it.
+	for y, row := range jl.Cells {
+		if uint64(len(row)) != uint64(jl.Width) {
+			return fmt.Errorf("layer of %dx%d cells has %d cells in row %d, expected %d", jl.Width, jl.Height, len(row), y, jl.Width)
+		}
+	}
+	if err := checkCellCount(int(jl.Width), int(jl.Height)); err != nil {
+		return err
+	}
+
+	cells := make([]Cell, 0, int(jl.Width)*int(jl.Height))
+	for _, row := range jl.Cells {
+		cells = append(cells, row...)
+	}
+
+	*l = Layer{
+		Width:  jl.Width,
+		Height: jl.Height,
+		Cells:  cells,
+		Meta:   jl.Meta,
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (xp XPFile) MarshalJSON() ([]byte, error) {
+	layers := xp.Layers
+	if layers == nil {
+		layers = []Layer{}
+	}
+
+	return marshalJSON(jsonXPFile{
+		Version: xp.Version,
+		Meta:    xp.Meta,
+		Layers:  layers,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (xp *XPFile) UnmarshalJSON(data []byte) error {
+	var jx jsonXPFile
+	if err := json.Unmarshal(data, &jx); err != nil {
+		return err
+	}
+
+	*xp = XPFile{
+		Version: jx.Version,
+		Layers:  jx.Layers,
+		Meta:    jx.Meta,
+	}
+
+	return nil
+}
+
+// EncodeJSON writes the XPFile to w as indented JSON, suitable for reviewing and diffing art changes.
+func EncodeJSON(w io.Writer, xp *XPFile) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(xp); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
+
+// DecodeJSON reads an XPFile from the JSON document in r.
+func DecodeJSON(r io.Reader) (*XPFile, error) {
+	var xp XPFile
+	if err := json.NewDecoder(r).Decode(&xp); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	return &xp, nil
+}
@@ -0,0 +1,198 @@
+package xploader
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTripTestData(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(testDataDir, "*.xp"))
+	if err != nil {
+		t.Fatalf("Failed to list test data: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			xp, err := LoadXPFile(file)
+			if err != nil {
+				t.Fatalf("Failed to load %q: %v", file, err)
+			}
+
+			var buf bytes.Buffer
+			if err := EncodeJSON(&buf, xp); err != nil {
+				t.Fatalf("Failed to encode JSON: %v", err)
+			}
+
+			decoded, err := DecodeJSON(&buf)
+			if err != nil {
+				t.Fatalf("Failed to decode JSON: %v", err)
+			}
+
+			opts := SaveOptions{RuneEncoder: CP437Encoder}
+			expected, err := Marshal(xp, opts)
+			if err != nil {
+				t.Fatalf("Failed to marshal original: %v", err)
+			}
+			actual, err := Marshal(decoded, opts)
+			if err != nil {
+				t.Fatalf("Failed to marshal decoded: %v", err)
+			}
+
+			if !bytes.Equal(expected, actual) {
+				t.Fatal("XP -> JSON -> XP round trip is not byte-identical")
+			}
+		})
+	}
+}
+
+func TestJSONRoundTripRawFile(t *testing.T) {
+	path := filepath.Join(testDataDir, "simple_plain.xp")
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %q: %v", path, err)
+	}
+
+	xp, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load %q: %v", path, err)
+	}
+
+	data, err := json.Marshal(xp)
+	if err != nil {
+		t.Fatalf("Failed to marshal JSON: %v", err)
+	}
+	var decoded XPFile
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+
+	marshaled, err := Marshal(&decoded, SaveOptions{RuneEncoder: CP437Encoder})
+	if err != nil {
+		t.Fatalf("Failed to marshal decoded XP file: %v", err)
+	}
+	if !bytes.Equal(original, marshaled) {
+		t.Fatal("Decoded JSON does not marshal to the original file")
+	}
+}
+
+func TestJSONColumnMajorAndMeta(t *testing.T) {
+	xp, err := LoadXPFileWithOptions(filepath.Join(testDataDir, "multilayer.xp"), LoadOptions{ColumnMajor: true, RuneDecoder: CP437Decoder})
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+	xp.Meta = &FileMeta{Author: "<artist & co>"}
+	xp.Layers[0].Meta = &LayerMeta{Name: "Base", Role: "floor"}
+
+	var buf bytes.Buffer
+	if err := EncodeJSON(&buf, xp); err != nil {
+		t.Fatalf("Failed to encode JSON: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<artist & co>") {
+		t.Error("Expected HTML characters not to be escaped")
+	}
+
+	decoded, err := DecodeJSON(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode JSON: %v", err)
+	}
+
+	if !reflect.DeepEqual(xp.Meta, decoded.Meta) || !reflect.DeepEqual(xp.Layers[0].Meta, decoded.Layers[0].Meta) {
+		t.Error("Metadata was not preserved")
+	}
+
+	for i, layer := range decoded.Layers {
+		if layer.ColumnMajor {
+			t.Errorf("Layer %d: expected decoded layer to be row-major", i)
+		}
+		for y := 0; y < int(layer.Height); y++ {
+			for x := 0; x < int(layer.Width); x++ {
+				if got, want := layer.At(x, y), xp.Layers[i].At(x, y); got != want {
+					t.Fatalf("Layer %d, cell (%d,%d): expected %+v, got %+v", i, x, y, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestCellJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		cell Cell
+		json string
+	}{
+		{
+			name: "CP437",
+			cell: Cell{Rune: '☺', Fg: Color{R: 255, G: 255, B: 255}, Bg: InvisibleColor},
+			json: `{"glyph":"☺","code":1,"fg":"#ffffff","bg":"#ff00ff"}`,
+		},
+		{
+			name: "Unmapped",
+			cell: Cell{Rune: '€', Fg: Color{R: 1}, Bg: Color{B: 2}},
+			json: `{"glyph":"€","code":8364,"fg":"#010000","bg":"#000002"}`,
+		},
+		{
+			name: "InvalidRune",
+			cell: Cell{Rune: -5},
+			json: `{"code":-5,"fg":"#000000","bg":"#000000"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.cell)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if string(data) != tt.json {
+				t.Errorf("Expected %s, got %s", tt.json, data)
+			}
+
+			var cell Cell
+			if err := json.Unmarshal(data, &cell); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+			if cell != tt.cell {
+				t.Errorf("Expected %+v, got %+v", tt.cell, cell)
+			}
+		})
+	}
+}
+
+func TestCellJSONCodeOnly(t *testing.T) {
+	var cell Cell
+	if err := json.Unmarshal([]byte(`{"code":176,"fg":"#FFFFFF","bg":"#000000"}`), &cell); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if cell.Rune != '░' {
+		t.Errorf("Expected glyph to be decoded from code, got %q", cell.Rune)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "BadColor", doc: `{"version":-1,"layers":[{"width":1,"height":1,"cells":[[{"code":65,"fg":"red","bg":"#000000"}]]}]}`},
+		{name: "MultiRuneGlyph", doc: `{"version":-1,"layers":[{"width":1,"height":1,"cells":[[{"glyph":"ab","code":65,"fg":"#000000","bg":"#000000"}]]}]}`},
+		{name: "MissingRow", doc: `{"version":-1,"layers":[{"width":1,"height":2,"cells":[[{"code":65,"fg":"#000000","bg":"#000000"}]]}]}`},
+		{name: "ShortRow", doc: `{"version":-1,"layers":[{"width":2,"height":1,"cells":[[{"code":65,"fg":"#000000","bg":"#000000"}]]}]}`},
+		{name: "Malformed", doc: `{"version":`},
+		{name: "ZeroWidth", doc: `{"version":-1,"layers":[{"width":0,"height":1,"cells":[[]]}]}`},
+		{name: "NoLayerData", doc: `{"version":-1,"layers":[{}]}`},
+		{name: "HugeWidth", doc: `{"version":-1,"layers":[{"width":4294967295,"height":4000,"cells":[` + strings.Repeat(`[],`, 3999) + `[]]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeJSON(strings.NewReader(tt.doc)); err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
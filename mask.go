@@ -0,0 +1,257 @@
+package xploader
+
+import (
+	"image"
+	"math/bits"
+)
+
+// Match selects the cell attributes compared when building masks. Attributes can be combined, e.g. MatchGlyph|MatchFg.
+// The zero value compares nothing, so every cell matches.
+type Match uint8
+
+const (
+	MatchGlyph Match = 1 << iota
+	MatchFg
+	MatchBg
+
+	MatchAll = MatchGlyph | MatchFg | MatchBg
+)
+
+// matches returns true when the selected attributes of both cells are equal.
+func (m Match) matches(a, b Cell) bool {
+	return (m&MatchGlyph == 0 || a.Rune == b.Rune) &&
+		(m&MatchFg == 0 || a.Fg == b.Fg) &&
+		(m&MatchBg == 0 || a.Bg == b.Bg)
+}
+
+// Connectivity selects which neighbours of a cell a flood fill spreads to.
+type Connectivity int
+
+const (
+	// Connect4 spreads to the cells above, below, left and right of a cell.
+	Connect4 Connectivity = iota
+	// Connect8 also spreads to the diagonal neighbours of a cell.
+	Connect8
+)
+
+// Mask is a selection of cells, typically sized to a layer, stored as a bitset.
+type Mask struct {
+	Width, Height int
+
+	bits []uint64
+}
+
+// NewMask returns an empty mask of the given dimensions. Like NewEmptyLayer, it treats negative dimensions as zero and
+// returns a mask of 0x0 cells for dimensions no layer can have.
+func NewMask(width, height int) *Mask {
+	width, height = clampLayerSize(width, height)
+	return &Mask{
+		Width:  width,
+		Height: height,
+		bits:   make([]uint64, (width*height+63)/64),
+	}
+}
+
+// RectMask returns a mask sized to the layer selecting the cells of the rectangle, clipped to the layer's bounds.
+func RectMask(l *Layer, r image.Rectangle) *Mask {
+	m := NewMask(int(l.Width), int(l.Height))
+	r = r.Intersect(l.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			m.Set(x, y, true)
+		}
+	}
+	return m
+}
+
+// MatchMask returns a mask sized to the layer selecting every cell whose attributes selected by match are equal to
+// those of the given cell, e.g. all cells with a red background.
+func MatchMask(l *Layer, cell Cell, match Match) *Mask {
+	return FuncMask(l, func(_, _ int, c Cell) bool {
+		return match.matches(c, cell)
+	})
+}
+
+// FuncMask returns a mask sized to the layer selecting every cell for which fn returns true.
+func FuncMask(l *Layer, fn func(x, y int, c Cell) bool) *Mask {
+	m := NewMask(int(l.Width), int(l.Height))
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			if fn(x, y, l.At(x, y)) {
+				m.Set(x, y, true)
+			}
+		}
+	}
+	return m
+}
+
+// FloodMask returns a mask sized to the layer selecting the region connected to (x, y) in which the attributes
+// selected by match are equal to those of the cell at (x, y), like REXPaint's fill tool. The mask is empty when
+// (x, y) is out of bounds.
+func FloodMask(l *Layer, x, y int, match Match, conn Connectivity) *Mask {
+	m := NewMask(int(l.Width), int(l.Height))
+	if !l.InBounds(x, y) {
+		return m
+	}
+
+	neighbours := []image.Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	if conn == Connect8 {
+		neighbours = append(neighbours, image.Point{-1, -1}, image.Point{1, -1}, image.Point{1, 1}, image.Point{-1, 1})
+	}
+
+	seed := l.At(x, y)
+	m.Set(x, y, true)
+	stack := []image.Point{{x, y}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, d := range neighbours {
+			n := p.Add(d)
+			if !l.InBounds(n.X, n.Y) || m.Get(n.X, n.Y) || !match.matches(l.At(n.X, n.Y), seed) {
+				continue
+			}
+			m.Set(n.X, n.Y, true)
+			stack = append(stack, n)
+		}
+	}
+
+	return m
+}
+
+// InBounds returns true when (x, y) lies within the mask.
+func (m *Mask) InBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.Width && y < m.Height
+}
+
+// Get returns true when the cell at (x, y) is selected. Cells out of bounds are never selected.
+func (m *Mask) Get(x, y int) bool {
+	if !m.InBounds(x, y) {
+		return false
+	}
+	i := y*m.Width + x
+	return m.bits[i/64]&(1<<(i%64)) != 0
+}
+
+// Set selects or deselects the cell at (x, y). It does nothing when (x, y) is out of bounds.
+func (m *Mask) Set(x, y int, selected bool) {
+	if !m.InBounds(x, y) {
+		return
+	}
+	i := y*m.Width + x
+	if selected {
+		m.bits[i/64] |= 1 << (i % 64)
+	} else {
+		m.bits[i/64] &^= 1 << (i % 64)
+	}
+}
+
+// Count returns the number of selected cells.
+func (m *Mask) Count() int {
+	n := 0
+	for _, w := range m.bits {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Bounds returns the smallest rectangle containing all selected cells, or an empty rectangle when none are.
+func (m *Mask) Bounds() image.Rectangle {
+	var r image.Rectangle
+	m.each(func(x, y int) {
+		r = r.Union(image.Rect(x, y, x+1, y+1))
+	})
+	return r
+}
+
+// Invert selects the cells that are not selected and deselects the others.
+func (m *Mask) Invert() {
+	for i := range m.bits {
+		m.bits[i] = ^m.bits[i]
+	}
+	// Keep the bits past the last cell clear, so Count stays correct.
+	if n := m.Width * m.Height % 64; n != 0 {
+		m.bits[len(m.bits)-1] &= 1<<n - 1
+	}
+}
+
+// Union selects the cells selected in o as well. Both masks must have the same dimensions.
+func (m *Mask) Union(o *Mask) {
+	m.combine(o, func(a, b uint64) uint64 { return a | b })
+}
+
+// Intersect deselects the cells that are not selected in o. Both masks must have the same dimensions.
+func (m *Mask) Intersect(o *Mask) {
+	m.combine(o, func(a, b uint64) uint64 { return a & b })
+}
+
+// Subtract deselects the cells that are selected in o. Both masks must have the same dimensions.
+func (m *Mask) Subtract(o *Mask) {
+	m.combine(o, func(a, b uint64) uint64 { return a &^ b })
+}
+
+// combine applies op to the words of both masks.
+func (m *Mask) combine(o *Mask, op func(a, b uint64) uint64) {
+	if m.Width != o.Width || m.Height != o.Height {
+		panic("xploader: mask dimensions differ")
+	}
+	for i := range m.bits {
+		m.bits[i] = op(m.bits[i], o.bits[i])
+	}
+}
+
+// each calls fn for every selected cell, row by row.
+func (m *Mask) each(fn func(x, y int)) {
+	for wi, w := range m.bits {
+		for w != 0 {
+			i := wi*64 + bits.TrailingZeros64(w)
+			fn(i%m.Width, i/m.Width)
+			w &= w - 1
+		}
+	}
+}
+
+// eachIn calls fn for every selected cell that lies within the layer.
+func (m *Mask) eachIn(l *Layer, fn func(x, y int)) {
+	m.each(func(x, y int) {
+		if l.InBounds(x, y) {
+			fn(x, y)
+		}
+	})
+}
+
+// FillMasked sets the cells selected by the mask to the given cell.
+func (l *Layer) FillMasked(m *Mask, cell Cell) {
+	m.eachIn(l, func(x, y int) {
+		l.Set(x, y, cell)
+	})
+}
+
+// RecolorMasked sets the colors selected by which, MatchFg and/or MatchBg, of the cells selected by the mask to the
+// given colors, keeping their glyphs.
+func (l *Layer) RecolorMasked(m *Mask, fg, bg Color, which Match) {
+	m.eachIn(l, func(x, y int) {
+		i := l.index(x, y)
+		if which&MatchFg != 0 {
+			l.Cells[i].Fg = fg
+		}
+		if which&MatchBg != 0 {
+			l.Cells[i].Bg = bg
+		}
+	})
+}
+
+// ClearMasked resets the cells selected by the mask to empty cells, see NewEmptyCell.
+func (l *Layer) ClearMasked(m *Mask) {
+	l.FillMasked(m, NewEmptyCell())
+}
+
+// CopyMasked copies the cells selected by the mask from src to the same positions on the layer. Cells outside either
+// layer are skipped.
+func (l *Layer) CopyMasked(src *Layer, m *Mask) {
+	m.eachIn(l, func(x, y int) {
+		if c, ok := src.TryGetCell(x, y); ok {
+			l.Set(x, y, c)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package xploader
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+// maskString renders the mask with '#' for selected and '.' for unselected cells.
+func maskString(m *Mask) string {
+	var sb strings.Builder
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			if m.Get(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func TestMaskSetGet(t *testing.T) {
+	m := NewMask(9, 9)
+	m.Set(8, 8, true)
+	m.Set(0, 0, true)
+	m.Set(-1, 0, true)
+	m.Set(9, 0, true)
+	if !m.Get(8, 8) || !m.Get(0, 0) || m.Get(1, 0) || m.Get(-1, 0) {
+		t.Error("unexpected selection")
+	}
+	if got := m.Count(); got != 2 {
+		t.Errorf("Count() = %d, want 2", got)
+	}
+	m.Set(0, 0, false)
+	if m.Get(0, 0) || m.Count() != 1 {
+		t.Error("cell not deselected")
+	}
+}
+
+func TestNewMaskHugeDimensions(t *testing.T) {
+	for _, dim := range [][2]int{{1<<32 | 2, 1 << 62}, {1<<32 + 1, 1}, {1 << 31, 1 << 31}} {
+		m := NewMask(dim[0], dim[1])
+		if m.Width != 0 || m.Height != 0 || len(m.bits) != 0 {
+			t.Errorf("NewMask(%d, %d): expected an empty mask, got %dx%d with %d words", dim[0], dim[1], m.Width, m.Height, len(m.bits))
+		}
+	}
+}
+
+func TestRectMask(t *testing.T) {
+	l := textLayer(t, "....\n....\n....")
+	m := RectMask(l, image.Rect(2, 1, 6, 5))
+	if got, want := maskString(m), "....\n..##\n..##\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if got, want := m.Bounds(), image.Rect(2, 1, 4, 3); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+}
+
+func TestFloodMask(t *testing.T) {
+	l := textLayer(t, "a.a\n.a.\na..")
+	tests := []struct {
+		name string
+		x, y int
+		conn Connectivity
+		want string
+	}{
+		{"4-connected", 0, 0, Connect4, "#..\n...\n...\n"},
+		{"8-connected", 0, 0, Connect8, "#.#\n.#.\n#..\n"},
+		{"4-connected dots", 1, 0, Connect4, ".#.\n...\n...\n"},
+		{"8-connected dots", 1, 0, Connect8, ".#.\n#.#\n.##\n"},
+		{"out of bounds", 3, 0, Connect8, "...\n...\n...\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskString(FloodMask(l, tt.x, tt.y, MatchGlyph, tt.conn)); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFloodMaskMatch(t *testing.T) {
+	red := Color{R: 255}
+	l := textLayer(t, "abc\nabc")
+	l.RecolorMasked(RectMask(l, image.Rect(1, 0, 3, 1)), red, red, MatchBg)
+
+	tests := []struct {
+		name  string
+		match Match
+		want  string
+	}{
+		{"glyph", MatchGlyph, ".#.\n.#.\n"},
+		{"background", MatchBg, ".##\n...\n"},
+		{"glyph and background", MatchGlyph | MatchBg, ".#.\n...\n"},
+		{"foreground", MatchFg, "###\n###\n"},
+		{"nothing", 0, "###\n###\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskString(FloodMask(l, 1, 0, tt.match, Connect4)); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchMask(t *testing.T) {
+	l := textLayer(t, "ab\nba")
+	if got, want := maskString(MatchMask(l, Cell{Rune: 'a'}, MatchGlyph)), "#.\n.#\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	m := FuncMask(l, func(x, _ int, c Cell) bool { return x == 1 && c.Rune == 'a' })
+	if got, want := maskString(m), "..\n.#\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestMaskSetOperations(t *testing.T) {
+	l := textLayer(t, "...\n...")
+	a := RectMask(l, image.Rect(0, 0, 2, 2))
+	b := RectMask(l, image.Rect(1, 0, 3, 1))
+
+	u := RectMask(l, image.Rect(0, 0, 2, 2))
+	u.Union(b)
+	if got, want := maskString(u), "###\n##.\n"; got != want {
+		t.Errorf("Union: got %q, want %q", got, want)
+	}
+
+	i := RectMask(l, image.Rect(0, 0, 2, 2))
+	i.Intersect(b)
+	if got, want := maskString(i), ".#.\n...\n"; got != want {
+		t.Errorf("Intersect: got %q, want %q", got, want)
+	}
+
+	a.Subtract(b)
+	if got, want := maskString(a), "#..\n##.\n"; got != want {
+		t.Errorf("Subtract: got %q, want %q", got, want)
+	}
+
+	a.Invert()
+	if got, want := maskString(a), ".##\n..#\n"; got != want {
+		t.Errorf("Invert: got %q, want %q", got, want)
+	}
+	if got := a.Count(); got != 3 {
+		t.Errorf("Count() after Invert = %d, want 3", got)
+	}
+}
+
+func TestMaskDimensionMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	NewMask(2, 2).Union(NewMask(2, 3))
+}
+
+func TestLayerMaskedOperations(t *testing.T) {
+	red := Color{R: 255}
+	l := textLayer(t, "abc\ndef")
+	m := RectMask(l, image.Rect(1, 0, 3, 1))
+
+	fill := l.Clone()
+	fill.FillMasked(m, Cell{Rune: 'x'})
+	if got, want := fill.Text(), "axx\ndef\n"; got != want {
+		t.Errorf("FillMasked: got %q, want %q", got, want)
+	}
+
+	clear := l.Clone()
+	clear.ClearMasked(m)
+	if got, want := clear.Text(), "a\ndef\n"; got != want {
+		t.Errorf("ClearMasked: got %q, want %q", got, want)
+	}
+	if !clear.At(2, 0).IsEmpty() {
+		t.Error("ClearMasked: cell not empty")
+	}
+
+	recolor := l.Clone()
+	recolor.RecolorMasked(m, red, red, MatchFg)
+	if c := recolor.At(1, 0); c.Rune != 'b' || c.Fg != red || c.Bg == red {
+		t.Errorf("RecolorMasked: got %+v", c)
+	}
+	if c := recolor.At(0, 0); c.Fg == red {
+		t.Errorf("RecolorMasked: unmasked cell recolored: %+v", c)
+	}
+
+	dst := textLayer(t, "..\n..\n..")
+	dst.CopyMasked(l, FuncMask(dst, func(x, y int, _ Cell) bool { return x == y || y == 2 }))
+	if got, want := dst.Text(), "a.\n.e\n..\n"; got != want {
+		t.Errorf("CopyMasked: got %q, want %q", got, want)
+	}
+}
+
+func TestLayerMaskedOperationsColumnMajor(t *testing.T) {
+	rows := textLayer(t, "ab\ncd")
+	l := &Layer{ColumnMajor: true, Width: 2, Height: 2, Cells: []Cell{rows.At(0, 0), rows.At(0, 1), rows.At(1, 0), rows.At(1, 1)}}
+	l.FillMasked(RectMask(l, image.Rect(1, 0, 2, 2)), Cell{Rune: 'x'})
+	if got, want := l.Text(), "ax\ncx\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package xploader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+)
+
+// SidecarSuffix is appended to the path of an .xp file to name its metadata sidecar, e.g. "foo.xp.json".
+const SidecarSuffix = ".json"
+
+// sidecarVersion is the version of the sidecar schema written by WriteMeta.
+const sidecarVersion = 1
+
+// ErrNotSidecar is returned by ReadMeta for JSON documents that are not metadata sidecars, such as an EncodeJSON export
+// that happens to be named like one. LoadXPFile ignores such files.
+var ErrNotSidecar = errors.New("not a metadata sidecar")
+
+// FileMeta holds metadata about an XP file that the .xp format itself cannot store.
+type FileMeta struct {
+	// Title is the title of the image.
+	Title string `json:"title,omitempty"`
+
+	// Author is the name of the artist.
+	Author string `json:"author,omitempty"`
+
+	// Properties holds arbitrary application defined key/value pairs.
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// LayerMeta holds metadata about a layer that the .xp format itself cannot store.
+type LayerMeta struct {
+	// Name is the human-readable name of the layer.
+	Name string `json:"name,omitempty"`
+
+	// Hidden layers are skipped by XPFile.Flatten.
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Locked marks the layer as read-only for editors. It is not enforced by this package.
+	Locked bool `json:"locked,omitempty"`
+
+	// Role describes the semantic purpose of the layer, e.g. "collision" or "spawn points".
+	Role string `json:"role,omitempty"`
+
+	// Properties holds arbitrary application defined key/value pairs.
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// clone returns a deep copy of the FileMeta.
+func (m *FileMeta) clone() *FileMeta {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	c.Properties = maps.Clone(m.Properties)
+	return &c
+}
+
+// clone returns a deep copy of the LayerMeta.
+func (m *LayerMeta) clone() *LayerMeta {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	c.Properties = maps.Clone(m.Properties)
+	return &c
+}
+
+// IsHidden will return true when the layer metadata marks the layer as hidden.
+func (l *Layer) IsHidden() bool {
+	return l.Meta != nil && l.Meta.Hidden
+}
+
+// HasMeta will return true when the XPFile or any of its layers carries metadata.
+func (xp *XPFile) HasMeta() bool {
+	if xp.Meta != nil {
+		return true
+	}
+	for i := range xp.Layers {
+		if xp.Layers[i].Meta != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sidecar is the JSON document stored in a sidecar file.
+type sidecar struct {
+	Version int          `json:"version"`
+	Meta    *FileMeta    `json:"meta,omitempty"`
+	Layers  []*LayerMeta `json:"layers,omitempty"`
+}
+
+// SidecarPath returns the path of the metadata sidecar belonging to the .xp file at path.
+func SidecarPath(path string) string {
+	return path + SidecarSuffix
+}
+
+// WriteMeta writes the metadata of the XPFile and its layers as a JSON sidecar document to w.
+func WriteMeta(w io.Writer, xp *XPFile) error {
+	doc := sidecar{
+		Version: sidecarVersion,
+		Meta:    xp.Meta,
+	}
+	for i := range xp.Layers {
+		if xp.Layers[i].Meta != nil {
+			doc.Layers = make([]*LayerMeta, len(xp.Layers))
+			break
+		}
+	}
+	for i := range doc.Layers {
+		doc.Layers[i] = xp.Layers[i].Meta
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	return nil
+}
+
+// ReadMeta reads a JSON sidecar document from r and applies the metadata it holds to the XPFile and its layers. Layer
+// metadata is matched by index; entries for layers the XPFile does not have are ignored. Documents without a valid
+// sidecar version, see WriteMeta, are rejected with an error matching ErrNotSidecar and leave the XPFile untouched.
+func ReadMeta(r io.Reader, xp *XPFile) error {
+	var doc sidecar
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if doc.Version < 1 {
+		return fmt.Errorf("%w: version %d", ErrNotSidecar, doc.Version)
+	}
+	if doc.Version > sidecarVersion {
+		return fmt.Errorf("unsupported metadata version %d", doc.Version)
+	}
+
+	xp.Meta = doc.Meta
+	for i := range xp.Layers {
+		if i < len(doc.Layers) {
+			xp.Layers[i].Meta = doc.Layers[i]
+		}
+	}
+
+	return nil
+}
+
+// loadSidecar applies the metadata from the sidecar file at path to the XPFile and returns true when it did. A missing
+// sidecar, or a JSON file at its path that is not a sidecar, is not an error.
+func loadSidecar(path string, xp *XPFile) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to open sidecar: %w", err)
+	}
+	defer f.Close()
+
+	err = ReadMeta(f, xp)
+	if errors.Is(err, ErrNotSidecar) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load sidecar %q: %w", path, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,293 @@
+package xploader
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newMetaXPFile(t *testing.T) *XPFile {
+	t.Helper()
+
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "multilayer.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load multilayer XP file: %v", err)
+	}
+
+	xp.Meta = &FileMeta{Title: "Title screen", Author: "malc0mn", Properties: map[string]string{"scene": "intro"}}
+	xp.Layers[1].Meta = &LayerMeta{Name: "Logo", Hidden: true, Locked: true, Role: "overlay"}
+
+	return xp
+}
+
+func TestSidecarRoundTrip(t *testing.T) {
+	xp := newMetaXPFile(t)
+
+	path := filepath.Join(t.TempDir(), "meta.xp")
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file: %v", err)
+	}
+
+	if _, err := os.Stat(SidecarPath(path)); err != nil {
+		t.Fatalf("Expected sidecar file to be written: %v", err)
+	}
+
+	reloaded, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reload XP file: %v", err)
+	}
+
+	assertXPFileEqual(xp, reloaded, t)
+	if !reflect.DeepEqual(xp.Meta, reloaded.Meta) {
+		t.Errorf("Expected file metadata %+v, got %+v", xp.Meta, reloaded.Meta)
+	}
+	if reloaded.Layers[0].Meta != nil {
+		t.Errorf("Expected no metadata on layer 0, got %+v", reloaded.Layers[0].Meta)
+	}
+	if !reflect.DeepEqual(xp.Layers[1].Meta, reloaded.Layers[1].Meta) {
+		t.Errorf("Expected layer 1 metadata %+v, got %+v", xp.Layers[1].Meta, reloaded.Layers[1].Meta)
+	}
+
+	withoutSidecar, err := LoadXPFileWithOptions(path, LoadOptions{RuneDecoder: CP437Decoder})
+	if err != nil {
+		t.Fatalf("Failed to reload XP file without sidecar: %v", err)
+	}
+	if withoutSidecar.HasMeta() {
+		t.Error("Expected no metadata when sidecar loading is disabled")
+	}
+}
+
+func TestSidecarRemovedWithoutMeta(t *testing.T) {
+	xp := newMetaXPFile(t)
+
+	path := filepath.Join(t.TempDir(), "meta.xp")
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file with metadata: %v", err)
+	}
+
+	xp.Meta = nil
+	xp.Layers[1].Meta = nil
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file without metadata: %v", err)
+	}
+
+	if _, err := os.Stat(SidecarPath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected stale sidecar to be removed, got: %v", err)
+	}
+
+	reloaded, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reload XP file: %v", err)
+	}
+	if reloaded.HasMeta() {
+		t.Errorf("Expected no metadata, got file %+v and layer %+v", reloaded.Meta, reloaded.Layers[1].Meta)
+	}
+}
+
+func TestSidecarRemovedAfterLoadingWithoutMeta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meta.xp")
+	if err := SaveXPFile(newMetaXPFile(t), path); err != nil {
+		t.Fatalf("Failed to save XP file with metadata: %v", err)
+	}
+
+	xp, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+	xp.Meta = nil
+	xp.Layers[1].Meta = nil
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file without metadata: %v", err)
+	}
+
+	if _, err := os.Stat(SidecarPath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected stale sidecar to be removed, got: %v", err)
+	}
+}
+
+func TestSidecarKeptWhenNotLoaded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meta.xp")
+	if err := SaveXPFile(newMetaXPFile(t), path); err != nil {
+		t.Fatalf("Failed to save XP file with metadata: %v", err)
+	}
+
+	// Loading without the sidecar and saving again must not throw away the metadata the XPFile never saw.
+	xp, err := LoadXPFileWithOptions(path, LoadOptions{RuneDecoder: CP437Decoder})
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file: %v", err)
+	}
+
+	reloaded, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reload XP file: %v", err)
+	}
+	if got := reloaded.Layers[1].Meta; got == nil || got.Name != "Logo" {
+		t.Errorf("Expected layer metadata to survive, got %+v", got)
+	}
+}
+
+func TestSidecarKeepsForeignFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "art.xp")
+	export := []byte(`{"exported": true}`)
+	if err := os.WriteFile(SidecarPath(path), export, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "simple.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file: %v", err)
+	}
+
+	if got, err := os.ReadFile(SidecarPath(path)); err != nil || !bytes.Equal(got, export) {
+		t.Errorf("Expected unrelated %s file to be left alone, got %q, %v", SidecarSuffix, got, err)
+	}
+}
+
+func TestSidecarKeepsXPCompatible(t *testing.T) {
+	xp := newMetaXPFile(t)
+	opts := SaveOptions{RuneEncoder: CP437Encoder, Sidecar: true}
+
+	dir := t.TempDir()
+	withMeta := filepath.Join(dir, "with.xp")
+	if err := SaveXPFileWithOptions(xp, withMeta, opts); err != nil {
+		t.Fatalf("Failed to save XP file with metadata: %v", err)
+	}
+
+	xp.Meta = nil
+	xp.Layers[1].Meta = nil
+	withoutMeta := filepath.Join(dir, "without.xp")
+	if err := SaveXPFileWithOptions(xp, withoutMeta, opts); err != nil {
+		t.Fatalf("Failed to save XP file without metadata: %v", err)
+	}
+
+	a, _ := os.ReadFile(withMeta)
+	b, _ := os.ReadFile(withoutMeta)
+	if !bytes.Equal(a, b) {
+		t.Error("Expected metadata not to affect the .xp file itself")
+	}
+
+	if _, err := os.Stat(SidecarPath(withoutMeta)); !os.IsNotExist(err) {
+		t.Errorf("Expected no sidecar for XP file without metadata, got err=%v", err)
+	}
+}
+
+func TestReadMeta(t *testing.T) {
+	xp := &XPFile{Layers: []Layer{*NewEmptyLayer(1, 1)}}
+
+	doc := `{"version": 1, "meta": {"author": "me"}, "layers": [{"name": "Base", "role": "collision"}, {"name": "Extra"}]}`
+	if err := ReadMeta(strings.NewReader(doc), xp); err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+
+	if xp.Meta == nil || xp.Meta.Author != "me" {
+		t.Errorf("Expected author %q, got %+v", "me", xp.Meta)
+	}
+	if m := xp.Layers[0].Meta; m == nil || m.Name != "Base" || m.Role != "collision" {
+		t.Errorf("Unexpected layer metadata %+v", m)
+	}
+}
+
+func TestReadMetaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "Malformed", doc: `{"version": 1,`},
+		{name: "FutureVersion", doc: `{"version": 99}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ReadMeta(strings.NewReader(tt.doc), &XPFile{}); err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestReadMetaNotSidecar(t *testing.T) {
+	for _, doc := range []string{`{"version": 0}`, `{"meta": {"author": "me"}}`, `{"version": -1, "layers": [{"width": 1}]}`} {
+		xp := &XPFile{Layers: []Layer{*NewEmptyLayer(1, 1)}}
+		if err := ReadMeta(strings.NewReader(doc), xp); !errors.Is(err, ErrNotSidecar) {
+			t.Errorf("%s: expected ErrNotSidecar, got %v", doc, err)
+		}
+		if xp.HasMeta() {
+			t.Errorf("%s: expected no metadata, got file %+v and layer %+v", doc, xp.Meta, xp.Layers[0].Meta)
+		}
+	}
+}
+
+func TestLoadIgnoresJSONExport(t *testing.T) {
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "multilayer.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	// An EncodeJSON export named like the sidecar of the file it was exported from.
+	path := filepath.Join(t.TempDir(), "art.xp")
+	if err := SaveXPFile(xp, path); err != nil {
+		t.Fatalf("Failed to save XP file: %v", err)
+	}
+	var export bytes.Buffer
+	if err := EncodeJSON(&export, xp); err != nil {
+		t.Fatalf("Failed to encode JSON: %v", err)
+	}
+	if err := os.WriteFile(SidecarPath(path), export.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reload XP file: %v", err)
+	}
+	if reloaded.HasMeta() {
+		t.Errorf("Expected the export not to be read as metadata, got file %+v and layer %+v", reloaded.Meta, reloaded.Layers[0].Meta)
+	}
+
+	if err := SaveXPFile(reloaded, path); err != nil {
+		t.Fatalf("Failed to save XP file: %v", err)
+	}
+	if got, err := os.ReadFile(SidecarPath(path)); err != nil || !bytes.Equal(got, export.Bytes()) {
+		t.Errorf("Expected the export to be left alone, got %v", err)
+	}
+}
+
+func TestLoadMalformedSidecar(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(testDataDir, "simple.xp"))
+	if err != nil {
+		t.Fatalf("Failed to read XP file: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "broken_meta.xp")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write XP file: %v", err)
+	}
+	if err := os.WriteFile(SidecarPath(path), []byte("not json"), 0o644); err != nil {
+		t.Fatalf("Failed to write sidecar: %v", err)
+	}
+
+	if _, err := LoadXPFile(path); err == nil {
+		t.Fatal("Expected error loading XP file with malformed sidecar, got nil")
+	}
+}
+
+func TestFlattenSkipsHiddenLayers(t *testing.T) {
+	xp := newMetaXPFile(t)
+
+	out := xp.Flatten()
+	for x := 0; x < int(out.Width); x++ {
+		if got := out.At(x, 14); !got.IsEmpty() {
+			t.Fatalf("Expected hidden layer not to be rendered, got %+v at (%d,14)", got, x)
+		}
+	}
+}
@@ -1,5 +1,13 @@
 package xploader
 
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"unsafe"
+)
+
 var (
 	// DefaultChar is the initial character of blank cells in REXPaint.
 	DefaultChar = ' '
@@ -18,6 +26,26 @@ type Color struct {
 	R, G, B uint8
 }
 
+// Hex returns the color in lowercase "#rrggbb" notation.
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// ParseHexColor parses a color in "#rrggbb" or "rrggbb" notation, case-insensitively.
+func ParseHexColor(s string) (Color, error) {
+	h := strings.TrimPrefix(s, "#")
+	if len(h) != 6 {
+		return Color{}, fmt.Errorf("invalid hex color %q", s)
+	}
+
+	var b [3]byte
+	if _, err := hex.Decode(b[:], []byte(h)); err != nil {
+		return Color{}, fmt.Errorf("invalid hex color %q", s)
+	}
+
+	return Color{R: b[0], G: b[1], B: b[2]}, nil
+}
+
 // IsInvisible will return true when the color is an absolute magenta. Absolute magenta is NEVER rendered: not as
 // foreground, not as background.
 func (c Color) IsInvisible() bool {
@@ -38,6 +66,12 @@ func (c Cell) IsEmpty() bool {
 		c.Bg.IsInvisible()
 }
 
+// HasVisibleGlyph will return true when the cell's glyph is rendered: it is not DefaultChar (or the null character)
+// and its foreground color is not invisible.
+func (c Cell) HasVisibleGlyph() bool {
+	return c.Rune != DefaultChar && c.Rune != 0 && !c.Fg.IsInvisible()
+}
+
 // NewEmptyCell returns a cell as REXPaint initialises it by default without the artist having touched it.
 func NewEmptyCell() Cell {
 	return Cell{
@@ -52,34 +86,166 @@ type Layer struct {
 	ColumnMajor bool
 	Width       uint32
 	Height      uint32
-	Cells       [][]Cell
+
+	// Cells holds all cells of the layer in a single contiguous slice. Row-major layers store the cell at (x, y) at
+	// index y*Width+x, column-major layers at index x*Height+y. Use At, Set, Row and Column to access cells without
+	// having to know the memory layout.
+	Cells []Cell
+
+	// Meta holds optional metadata that is not part of the .xp format, see LayerMeta.
+	Meta *LayerMeta
+}
+
+// InBounds reports whether the logical coordinates (x, y) lie within the layer.
+func (l *Layer) InBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < int(l.Width) && y < int(l.Height)
+}
+
+// index returns the position of the cell at logical coordinates (x, y) in Cells. It panics when the coordinates are
+// out of range, like indexing a slice would.
+func (l *Layer) index(x, y int) int {
+	if !l.InBounds(x, y) {
+		panic(fmt.Sprintf("xploader: cell (%d,%d) out of range for layer of %dx%d cells", x, y, l.Width, l.Height))
+	}
+	if l.ColumnMajor {
+		return x*int(l.Height) + y
+	}
+	return y*int(l.Width) + x
 }
 
 // GetCell returns the cell at logical coordinates (x, y) based on the layer's memory layout.
 // It automatically adjusts indexing if the layer was loaded in column-major order.
 func (l *Layer) GetCell(x, y int) Cell {
+	return l.Cells[l.index(x, y)]
+}
+
+// At returns the cell at logical coordinates (x, y). It is equivalent to GetCell and panics when the coordinates are
+// out of range.
+func (l *Layer) At(x, y int) Cell {
+	return l.Cells[l.index(x, y)]
+}
+
+// Set replaces the cell at logical coordinates (x, y). It panics when the coordinates are out of range.
+func (l *Layer) Set(x, y int, cell Cell) {
+	l.Cells[l.index(x, y)] = cell
+}
+
+// TryGetCell returns the cell at logical coordinates (x, y) and true, or an empty Cell and false when the coordinates
+// are out of range.
+func (l *Layer) TryGetCell(x, y int) (Cell, bool) {
+	if !l.InBounds(x, y) {
+		return Cell{}, false
+	}
+	return l.Cells[l.index(x, y)], true
+}
+
+// SetCell replaces the cell at logical coordinates (x, y) and returns true. Coordinates out of range are ignored and
+// false is returned.
+func (l *Layer) SetCell(x, y int, cell Cell) bool {
+	if !l.InBounds(x, y) {
+		return false
+	}
+	l.Cells[l.index(x, y)] = cell
+	return true
+}
+
+// Fill sets every cell of the layer to the given cell.
+func (l *Layer) Fill(cell Cell) {
+	for i := range l.Cells {
+		l.Cells[i] = cell
+	}
+}
+
+// FillRect sets the cells of the rectangle with its top left corner at (x, y) and the given width and height to the
+// given cell. The rectangle is clipped to the layer's bounds.
+func (l *Layer) FillRect(x, y, width, height int, cell Cell) {
+	x0, y0 := max(x, 0), max(y, 0)
+	x1, y1 := min(x+width, int(l.Width)), min(y+height, int(l.Height))
+
+	for cy := y0; cy < y1; cy++ {
+		for cx := x0; cx < x1; cx++ {
+			l.Cells[l.index(cx, cy)] = cell
+		}
+	}
+}
+
+// Row returns the cells of row y from left to right. For row-major layers the returned slice shares its storage with
+// the layer, for column-major layers it is a copy.
+func (l *Layer) Row(y int) []Cell {
+	if !l.ColumnMajor {
+		start := l.index(0, y)
+		return l.Cells[start : start+int(l.Width) : start+int(l.Width)]
+	}
+
+	row := make([]Cell, l.Width)
+	for x := range row {
+		row[x] = l.At(x, y)
+	}
+	return row
+}
+
+// Column returns the cells of column x from top to bottom. For column-major layers the returned slice shares its
+// storage with the layer, for row-major layers it is a copy.
+func (l *Layer) Column(x int) []Cell {
 	if l.ColumnMajor {
-		return l.Cells[x][y]
+		start := l.index(x, 0)
+		return l.Cells[start : start+int(l.Height) : start+int(l.Height)]
+	}
+
+	column := make([]Cell, l.Height)
+	for y := range column {
+		column[y] = l.At(x, y)
 	}
-	return l.Cells[y][x]
+	return column
 }
 
-// NewEmptyLayer returns a new layer of the given dimensions initialized with empty cells.
+// Clone returns a deep copy of the layer.
+func (l *Layer) Clone() *Layer {
+	c := *l
+	c.Cells = append([]Cell(nil), l.Cells...)
+	c.Meta = l.Meta.clone()
+	return &c
+}
+
+// checkCells verifies that the number of cells matches the layer's dimensions.
+func (l *Layer) checkCells() error {
+	if expected := uint64(l.Width) * uint64(l.Height); uint64(len(l.Cells)) != expected {
+		return fmt.Errorf("layer of %dx%d cells has %d cells, expected %d", l.Width, l.Height, len(l.Cells), expected)
+	}
+	return nil
+}
+
+// maxLayerCells is the largest number of cells NewEmptyLayer allocates. It is far beyond anything REXPaint produces.
+const maxLayerCells = 1 << 32
+
+// clampLayerSize treats negative dimensions as zero and dimensions no layer can have as 0x0: dimensions that do not fit
+// in a uint32, and cell counts beyond maxLayerCells or too large to allocate.
+func clampLayerSize(width, height int) (int, int) {
+	width, height = max(width, 0), max(height, 0)
+	if uint64(width) > math.MaxUint32 || uint64(height) > math.MaxUint32 {
+		return 0, 0
+	}
+	if cells := uint64(width) * uint64(height); cells > maxLayerCells || cells > math.MaxInt/uint64(unsafe.Sizeof(Cell{})) {
+		return 0, 0
+	}
+	return width, height
+}
+
+// NewEmptyLayer returns a new row-major layer of the given dimensions initialized with empty cells. Negative
+// dimensions are treated as zero. Dimensions no layer can have, because they do not fit in the uint32 Width and
+// Height fields or hold more than 2^32 cells, yield a layer of 0x0 cells.
 func NewEmptyLayer(width, height int) *Layer {
+	width, height = clampLayerSize(width, height)
+
+	cells := make([]Cell, width*height)
+	for i := range cells {
+		cells[i] = NewEmptyCell()
+	}
+
 	return &Layer{
 		Width:  uint32(width),
 		Height: uint32(height),
-		Cells: func() [][]Cell {
-			tiles := make([][]Cell, height)
-			for y := 0; y < height; y++ {
-				row := make([]Cell, width)
-				for x := 0; x < width; x++ {
-					row[x] = NewEmptyCell()
-				}
-				tiles[y] = row
-			}
-			return tiles
-		}(),
+		Cells:  cells,
 	}
 }
 
@@ -87,6 +253,13 @@ func NewEmptyLayer(width, height int) *Layer {
 type XPFile struct {
 	Version int32
 	Layers  []Layer
+
+	// Meta holds optional metadata that is not part of the .xp format, see FileMeta.
+	Meta *FileMeta
+
+	// sidecar is the path of the metadata sidecar the XPFile was loaded from or last saved to. It is the only sidecar
+	// saving the XPFile without metadata removes: any other sidecar may hold metadata the XPFile never knew about.
+	sidecar string
 }
 
 // AddLayer adds the given layer to the XPFile.
@@ -1,6 +1,9 @@
 package xploader
 
 import (
+	"bytes"
+	"math"
+	"math/bits"
 	"testing"
 )
 
@@ -103,15 +106,11 @@ func TestGetCellColumnMajor(t *testing.T) {
 		ColumnMajor: true,
 		Width:       2,
 		Height:      2,
-		Cells: [][]Cell{
-			{
-				{Rune: 'A'}, // column 0, row 0
-				{Rune: 'B'}, // column 0, row 1
-			},
-			{
-				{Rune: 'C'}, // column 1, row 0
-				{Rune: 'D'}, // column 1, row 1
-			},
+		Cells: []Cell{
+			{Rune: 'A'}, // column 0, row 0
+			{Rune: 'B'}, // column 0, row 1
+			{Rune: 'C'}, // column 1, row 0
+			{Rune: 'D'}, // column 1, row 1
 		},
 	}
 
@@ -132,3 +131,280 @@ func TestGetCellColumnMajor(t *testing.T) {
 		}
 	}
 }
+
+func TestGetCellOutOfRange(t *testing.T) {
+	layer := NewEmptyLayer(3, 2)
+
+	for _, c := range []struct{ x, y int }{{-1, 0}, {0, -1}, {3, 0}, {0, 2}, {3, 1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("GetCell(%d,%d): expected panic", c.x, c.y)
+				}
+			}()
+			layer.GetCell(c.x, c.y)
+		}()
+	}
+}
+
+func TestLayerAccessors(t *testing.T) {
+	for _, columnMajor := range []bool{false, true} {
+		layer := &Layer{ColumnMajor: columnMajor, Width: 3, Height: 2, Cells: make([]Cell, 6)}
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 3; x++ {
+				layer.Set(x, y, Cell{Rune: rune('a' + y*3 + x)})
+			}
+		}
+
+		if got := layer.At(2, 1); got.Rune != 'f' {
+			t.Errorf("ColumnMajor=%v: At(2,1): got %q, want 'f'", columnMajor, got.Rune)
+		}
+		if got := layer.GetCell(1, 0); got.Rune != 'b' {
+			t.Errorf("ColumnMajor=%v: GetCell(1,0): got %q, want 'b'", columnMajor, got.Rune)
+		}
+
+		row := layer.Row(1)
+		if len(row) != 3 || row[0].Rune != 'd' || row[1].Rune != 'e' || row[2].Rune != 'f' {
+			t.Errorf("ColumnMajor=%v: Row(1): got %+v", columnMajor, row)
+		}
+
+		column := layer.Column(2)
+		if len(column) != 2 || column[0].Rune != 'c' || column[1].Rune != 'f' {
+			t.Errorf("ColumnMajor=%v: Column(2): got %+v", columnMajor, column)
+		}
+
+		clone := layer.Clone()
+		clone.Set(0, 0, Cell{Rune: 'z'})
+		if layer.At(0, 0).Rune != 'a' {
+			t.Errorf("ColumnMajor=%v: modifying clone changed the original layer", columnMajor)
+		}
+	}
+}
+
+func TestLayerRowColumnSharing(t *testing.T) {
+	rowMajor := NewEmptyLayer(3, 2)
+	rowMajor.Row(1)[2] = Cell{Rune: 'x'}
+	if rowMajor.At(2, 1).Rune != 'x' {
+		t.Error("Expected Row of a row-major layer to share storage with the layer")
+	}
+
+	columnMajor := &Layer{ColumnMajor: true, Width: 3, Height: 2, Cells: make([]Cell, 6)}
+	columnMajor.Column(2)[1] = Cell{Rune: 'y'}
+	if columnMajor.At(2, 1).Rune != 'y' {
+		t.Error("Expected Column of a column-major layer to share storage with the layer")
+	}
+}
+
+func FuzzNewEmptyLayer(f *testing.F) {
+	f.Add(0, 0)
+	f.Add(1, 1)
+	f.Add(80, 25)
+	f.Add(-1, 5)
+	f.Add(5, -1)
+	f.Add(1<<32|2, 1<<62)
+	f.Add(1<<32+1, 1)
+	f.Add(1<<31, 1<<31)
+
+	f.Fuzz(func(t *testing.T, width, height int) {
+		// Dimensions that don't fit the layer must yield an empty layer instead of wrapping around or panicking.
+		w, h := uint64(max(width, 0)), uint64(max(height, 0))
+		hi, cells := bits.Mul64(w, h)
+		if w > math.MaxUint32 || h > math.MaxUint32 || hi != 0 || cells > 1<<32 {
+			if layer := NewEmptyLayer(width, height); layer.Width != 0 || layer.Height != 0 || len(layer.Cells) != 0 {
+				t.Fatalf("NewEmptyLayer(%d, %d): expected an empty layer, got %dx%d with %d cells", width, height, layer.Width, layer.Height, len(layer.Cells))
+			}
+			return
+		}
+		// Valid but large layers are not worth allocating for every input.
+		if cells > 1<<16 {
+			return
+		}
+
+		layer := NewEmptyLayer(width, height)
+		if err := layer.checkCells(); err != nil {
+			t.Fatalf("NewEmptyLayer(%d, %d) returned an inconsistent layer: %v", width, height, err)
+		}
+
+		data, err := Marshal(newXpFile(*layer, t), SaveOptions{})
+		if width <= 0 || height <= 0 {
+			if err == nil {
+				t.Fatalf("Expected error marshaling layer of %dx%d cells, got nil", width, height)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("Failed to marshal layer of %dx%d cells: %v", width, height, err)
+		}
+
+		xp, err := LoadXPFromReader(bytes.NewReader(data), LoadOptions{})
+		if err != nil {
+			t.Fatalf("Failed to reload layer of %dx%d cells: %v", width, height, err)
+		}
+		assertXPFileEqual(newXpFile(*layer, t), xp, t)
+	})
+}
+
+func TestLayerInBounds(t *testing.T) {
+	layer := NewEmptyLayer(3, 2)
+
+	tests := []struct {
+		x, y   int
+		expect bool
+	}{
+		{x: 0, y: 0, expect: true},
+		{x: 2, y: 1, expect: true},
+		{x: -1, y: 0, expect: false},
+		{x: 0, y: -1, expect: false},
+		{x: 3, y: 0, expect: false},
+		{x: 0, y: 2, expect: false},
+	}
+
+	for _, tt := range tests {
+		if got := layer.InBounds(tt.x, tt.y); got != tt.expect {
+			t.Errorf("InBounds(%d,%d): got %v, want %v", tt.x, tt.y, got, tt.expect)
+		}
+	}
+}
+
+func TestLayerTryGetSetCell(t *testing.T) {
+	for _, columnMajor := range []bool{false, true} {
+		layer := &Layer{ColumnMajor: columnMajor, Width: 3, Height: 2, Cells: make([]Cell, 6)}
+		x := Cell{Rune: 'x'}
+
+		if !layer.SetCell(2, 1, x) {
+			t.Fatalf("ColumnMajor=%v: SetCell(2,1): expected true", columnMajor)
+		}
+		if got, ok := layer.TryGetCell(2, 1); !ok || got != x {
+			t.Errorf("ColumnMajor=%v: TryGetCell(2,1): got %+v, %v", columnMajor, got, ok)
+		}
+		if layer.GetCell(2, 1) != x {
+			t.Errorf("ColumnMajor=%v: GetCell(2,1) does not return the cell set by SetCell", columnMajor)
+		}
+
+		if layer.SetCell(3, 1, x) {
+			t.Errorf("ColumnMajor=%v: SetCell(3,1): expected false", columnMajor)
+		}
+		if layer.SetCell(-1, 0, x) {
+			t.Errorf("ColumnMajor=%v: SetCell(-1,0): expected false", columnMajor)
+		}
+		if got, ok := layer.TryGetCell(0, 2); ok || got != (Cell{}) {
+			t.Errorf("ColumnMajor=%v: TryGetCell(0,2): got %+v, %v", columnMajor, got, ok)
+		}
+
+		count := 0
+		for _, c := range layer.Cells {
+			if c == x {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Errorf("ColumnMajor=%v: expected exactly 1 modified cell, got %d", columnMajor, count)
+		}
+	}
+}
+
+func TestLayerFill(t *testing.T) {
+	layer := NewEmptyLayer(4, 3)
+	fill := Cell{Rune: '#', Fg: Color{R: 1}, Bg: Color{B: 2}}
+
+	layer.Fill(fill)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			if layer.GetCell(x, y) != fill {
+				t.Fatalf("Fill: cell (%d,%d) not filled", x, y)
+			}
+		}
+	}
+}
+
+func TestLayerFillRect(t *testing.T) {
+	for _, columnMajor := range []bool{false, true} {
+		layer := &Layer{ColumnMajor: columnMajor, Width: 4, Height: 3, Cells: make([]Cell, 12)}
+		fill := Cell{Rune: '#'}
+
+		// Partially out of bounds on the top left.
+		layer.FillRect(-1, -1, 3, 2, fill)
+
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 4; x++ {
+				expect := x < 2 && y < 1
+				if got := layer.GetCell(x, y) == fill; got != expect {
+					t.Errorf("ColumnMajor=%v: cell (%d,%d): filled=%v, want %v", columnMajor, x, y, got, expect)
+				}
+			}
+		}
+
+		// Entirely out of bounds.
+		layer.FillRect(10, 10, 2, 2, Cell{Rune: '!'})
+		layer.FillRect(1, 1, -2, 5, Cell{Rune: '!'})
+		for _, c := range layer.Cells {
+			if c.Rune == '!' {
+				t.Fatalf("ColumnMajor=%v: out of bounds FillRect modified the layer", columnMajor)
+			}
+		}
+	}
+}
+
+func TestCellHasVisibleGlyph(t *testing.T) {
+	tests := []struct {
+		name   string
+		cell   Cell
+		expect bool
+	}{
+		{name: "Glyph", cell: Cell{Rune: 'x'}, expect: true},
+		{name: "Space", cell: Cell{Rune: ' '}, expect: false},
+		{name: "Null", cell: Cell{Rune: 0}, expect: false},
+		{name: "InvisibleForeground", cell: Cell{Rune: 'x', Fg: InvisibleColor}, expect: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cell.HasVisibleGlyph(); got != tt.expect {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expect, got)
+		}
+	}
+}
+
+func TestLayerCloneMeta(t *testing.T) {
+	layer := NewEmptyLayer(1, 1)
+	layer.Meta = &LayerMeta{Name: "original", Properties: map[string]string{"k": "v"}}
+
+	clone := layer.Clone()
+	clone.Meta.Name = "clone"
+	clone.Meta.Properties["k"] = "changed"
+
+	if layer.Meta.Name != "original" || layer.Meta.Properties["k"] != "v" {
+		t.Errorf("Modifying the clone's metadata changed the original: %+v", layer.Meta)
+	}
+}
+
+func TestColorHex(t *testing.T) {
+	if got := (Color{R: 255, G: 8, B: 171}).Hex(); got != "#ff08ab" {
+		t.Errorf("Expected #ff08ab, got %s", got)
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		in     string
+		expect Color
+		ok     bool
+	}{
+		{in: "#ff08ab", expect: Color{R: 255, G: 8, B: 171}, ok: true},
+		{in: "FF08AB", expect: Color{R: 255, G: 8, B: 171}, ok: true},
+		{in: "#000000", expect: Color{}, ok: true},
+		{in: "#fff", ok: false},
+		{in: "#gg0000", ok: false},
+		{in: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHexColor(tt.in)
+		if tt.ok != (err == nil) {
+			t.Errorf("ParseHexColor(%q): unexpected error state: %v", tt.in, err)
+			continue
+		}
+		if tt.ok && got != tt.expect {
+			t.Errorf("ParseHexColor(%q): expected %+v, got %+v", tt.in, tt.expect, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package ansi renders REXPaint layers to terminals using ANSI escape sequences.
+package ansi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/malc0mn/xploder"
+)
+
+// ColorMode selects the kind of color escape sequences that are written.
+type ColorMode int
+
+const (
+	// TrueColor writes 24-bit colors and reproduces every color exactly.
+	TrueColor ColorMode = iota
+	// Color256 quantizes colors to the xterm 256 color palette.
+	Color256
+	// Color16 quantizes colors to the 16 standard ANSI colors.
+	Color16
+)
+
+// String returns the name of the color mode.
+func (m ColorMode) String() string {
+	switch m {
+	case TrueColor:
+		return "truecolor"
+	case Color256:
+		return "256"
+	case Color16:
+		return "16"
+	}
+	return "ColorMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// Options controls how layers are rendered.
+type Options struct {
+	// Mode selects the color escape sequences. Defaults to TrueColor.
+	Mode ColorMode
+
+	// Border frames the image with a single line box.
+	Border bool
+}
+
+// DetectColorMode guesses the color capabilities of the terminal from the COLORTERM and TERM environment variables.
+func DetectColorMode() ColorMode {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"), strings.Contains(term, "direct"):
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return Color256
+	}
+	return Color16
+}
+
+// xterm256 holds the xterm 256 color palette entries quantization picks from. The first 16 entries are left out as
+// most terminals let users redefine them.
+var xterm256 = func() []xploader.Color {
+	p := make([]xploader.Color, 0, 240)
+	for n := 16; n < 256; n++ {
+		p = append(p, xploader.XTerm256Color(uint8(n)))
+	}
+	return p
+}()
+
+// WriteXPFile flattens the XPFile, skipping hidden layers, and renders the result to w.
+func WriteXPFile(w io.Writer, xp *xploader.XPFile, opts Options) error {
+	return WriteLayer(w, xp.Flatten(), opts)
+}
+
+// WriteLayer renders the layer to w, one line per row. Color sequences are only written when a color changes and the
+// attributes are reset at the end of every line so backgrounds never bleed into the rest of the terminal. Cells with an
+// InvisibleColor background use the terminal's default background, and glyphs with an InvisibleColor foreground are
+// not drawn.
+func WriteLayer(w io.Writer, layer *xploader.Layer, opts Options) error {
+	bw := bufio.NewWriter(w)
+	r := renderer{w: bw, mode: opts.Mode, cache: map[xploader.Color]int{}}
+
+	width := int(layer.Width)
+	if opts.Border {
+		bw.WriteString("┌" + strings.Repeat("─", width) + "┐\n")
+	}
+
+	for y := 0; y < int(layer.Height); y++ {
+		if opts.Border {
+			bw.WriteString("│")
+		}
+		for x := 0; x < width; x++ {
+			r.cell(layer.At(x, y))
+		}
+		r.reset()
+		if opts.Border {
+			bw.WriteString("│")
+		}
+		bw.WriteByte('\n')
+	}
+
+	if opts.Border {
+		bw.WriteString("└" + strings.Repeat("─", width) + "┘\n")
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write layer: %w", err)
+	}
+	return nil
+}
+
+// renderer tracks the colors currently set on the terminal, as SGR parameters, to avoid redundant sequences. An empty
+// string means the terminal default.
+type renderer struct {
+	w      *bufio.Writer
+	mode   ColorMode
+	fg, bg string
+	cache  map[xploader.Color]int
+}
+
+// cell writes a single cell.
+func (r *renderer) cell(cell xploader.Cell) {
+	glyph := cell.Rune
+	var fg, bg string
+
+	if cell.Bg.IsInvisible() {
+		bg = ""
+	} else {
+		bg = r.color(cell.Bg, true)
+	}
+	if !cell.HasVisibleGlyph() {
+		// Keep the current foreground: it is not visible behind a space anyway.
+		glyph, fg = ' ', r.fg
+	} else {
+		fg = r.color(cell.Fg, false)
+	}
+
+	var params []string
+	if fg != r.fg {
+		if fg == "" {
+			params = append(params, "39")
+		} else {
+			params = append(params, fg)
+		}
+	}
+	if bg != r.bg {
+		if bg == "" {
+			params = append(params, "49")
+		} else {
+			params = append(params, bg)
+		}
+	}
+	if len(params) > 0 {
+		r.w.WriteString("\x1b[" + strings.Join(params, ";") + "m")
+		r.fg, r.bg = fg, bg
+	}
+
+	r.w.WriteRune(glyph)
+}
+
+// reset restores the terminal's default colors when any color is set.
+func (r *renderer) reset() {
+	if r.fg != "" || r.bg != "" {
+		r.w.WriteString("\x1b[0m")
+		r.fg, r.bg = "", ""
+	}
+}
+
+// color returns the SGR parameters selecting c as foreground or background color in the renderer's color mode.
+func (r *renderer) color(c xploader.Color, background bool) string {
+	switch r.mode {
+	case Color256:
+		base := 38
+		if background {
+			base = 48
+		}
+		return fmt.Sprintf("%d;5;%d", base, 16+r.nearest(c, xterm256))
+	case Color16:
+		n := r.nearest(c, xploader.ANSIPalette[:])
+		base := 30
+		if background {
+			base = 40
+		}
+		if n >= 8 {
+			// The aixterm bright colors, as bold only brightens the foreground.
+			base += 60
+		}
+		return strconv.Itoa(base + n%8)
+	}
+
+	base := 38
+	if background {
+		base = 48
+	}
+	return fmt.Sprintf("%d;2;%d;%d;%d", base, c.R, c.G, c.B)
+}
+
+// nearest returns the index of the palette color closest to c. Results are cached, as images tend to reuse a small
+// number of colors and the renderer only ever quantizes against a single palette.
+func (r *renderer) nearest(c xploader.Color, palette []xploader.Color) int {
+	if n, ok := r.cache[c]; ok {
+		return n
+	}
+	n := xploader.NearestPaletteIndex(c, palette)
+	r.cache[c] = n
+	return n
+}
@@ -0,0 +1,82 @@
+package ansi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+func testLayer() *xploader.Layer {
+	layer := xploader.NewEmptyLayer(4, 1)
+	layer.Set(0, 0, xploader.Cell{Rune: 'a', Fg: xploader.Color{R: 255}, Bg: xploader.Color{B: 255}})
+	layer.Set(1, 0, xploader.Cell{Rune: 'b', Fg: xploader.Color{R: 255}, Bg: xploader.Color{B: 255}})
+	layer.Set(2, 0, xploader.Cell{Rune: 'c', Fg: xploader.Color{G: 255}, Bg: xploader.InvisibleColor})
+	layer.Set(3, 0, xploader.Cell{Rune: 'd', Fg: xploader.InvisibleColor, Bg: xploader.Color{B: 255}})
+	return layer
+}
+
+func TestWriteLayer(t *testing.T) {
+	tests := []struct {
+		mode ColorMode
+		want string
+	}{
+		{TrueColor, "\x1b[38;2;255;0;0;48;2;0;0;255mab\x1b[38;2;0;255;0;49mc\x1b[48;2;0;0;255m \x1b[0m\n"},
+		{Color256, "\x1b[38;5;196;48;5;21mab\x1b[38;5;46;49mc\x1b[48;5;21m \x1b[0m\n"},
+		{Color16, "\x1b[31;44mab\x1b[32;49mc\x1b[44m \x1b[0m\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteLayer(&buf, testLayer(), Options{Mode: tt.mode}); err != nil {
+				t.Fatalf("Failed to write layer: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLayerEmptyCells(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, xploader.NewEmptyLayer(3, 2), Options{}); err != nil {
+		t.Fatalf("Failed to write layer: %v", err)
+	}
+
+	if got, want := buf.String(), "   \n   \n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestWriteLayerBorder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, xploader.NewEmptyLayer(2, 1), Options{Border: true}); err != nil {
+		t.Fatalf("Failed to write layer: %v", err)
+	}
+
+	if got, want := buf.String(), "┌──┐\n│  │\n└──┘\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestDetectColorMode(t *testing.T) {
+	tests := []struct {
+		colorterm, term string
+		want            ColorMode
+	}{
+		{"truecolor", "xterm", TrueColor},
+		{"", "xterm-256color", Color256},
+		{"", "xterm-direct", TrueColor},
+		{"", "vt100", Color16},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("COLORTERM", tt.colorterm)
+		t.Setenv("TERM", tt.term)
+		if got := DetectColorMode(); got != tt.want {
+			t.Errorf("COLORTERM=%q TERM=%q: got %v, want %v", tt.colorterm, tt.term, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package html renders REXPaint layers as HTML for previews in the browser.
+package html
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/malc0mn/xploder"
+)
+
+// defaultStyle is the inline style of the pre element, keeping the lines of block glyphs together.
+const defaultStyle = "font-family:monospace;line-height:1"
+
+// Options controls how layers are rendered.
+type Options struct {
+	// Class is set as the class attribute of the pre element when not empty.
+	Class string
+
+	// Style is the inline style of the pre element. Defaults to a monospace font with a line height of 1, so block
+	// glyphs of consecutive lines touch.
+	Style string
+}
+
+// escaper escapes the characters that are special in HTML text and attributes.
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&#34;", "'", "&#39;")
+
+// WriteXPFile flattens the XPFile, skipping hidden layers, and renders the result to w.
+func WriteXPFile(w io.Writer, xp *xploader.XPFile, opts Options) error {
+	return WriteLayer(w, xp.Flatten(), opts)
+}
+
+// WriteLayer renders the layer to w as a pre element with one line per row. Runs of cells sharing the same colors
+// are merged into a single span with an inline style. Cells with an InvisibleColor background are transparent, and
+// glyphs with an InvisibleColor foreground are not drawn.
+func WriteLayer(w io.Writer, layer *xploader.Layer, opts Options) error {
+	bw := bufio.NewWriter(w)
+
+	style := opts.Style
+	if style == "" {
+		style = defaultStyle
+	}
+	bw.WriteString("<pre")
+	if opts.Class != "" {
+		bw.WriteString(` class="` + escaper.Replace(opts.Class) + `"`)
+	}
+	bw.WriteString(` style="` + escaper.Replace(style) + `">`)
+
+	for y := 0; y < int(layer.Height); y++ {
+		if y > 0 {
+			bw.WriteByte('\n')
+		}
+		writeRow(bw, layer, y)
+	}
+
+	bw.WriteString("</pre>\n")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write layer: %w", err)
+	}
+	return nil
+}
+
+// span is a run of glyphs sharing the same style.
+type span struct {
+	style string
+	text  strings.Builder
+}
+
+// writeRow writes a row of the layer as a sequence of spans.
+func writeRow(w *bufio.Writer, layer *xploader.Layer, y int) {
+	var cur span
+	var fg, bg string
+
+	flush := func() {
+		if cur.text.Len() == 0 {
+			return
+		}
+		if cur.style == "" {
+			w.WriteString(cur.text.String())
+		} else {
+			w.WriteString(`<span style="` + cur.style + `">` + cur.text.String() + "</span>")
+		}
+		cur.text.Reset()
+	}
+
+	for x := 0; x < int(layer.Width); x++ {
+		cell := layer.At(x, y)
+
+		glyph := cell.Rune
+		cbg := ""
+		if !cell.Bg.IsInvisible() {
+			cbg = cell.Bg.Hex()
+		}
+		cfg := fg
+		if !cell.HasVisibleGlyph() {
+			// The foreground of a space is not visible, so keep the current one to extend the run.
+			glyph = ' '
+		} else {
+			cfg = cell.Fg.Hex()
+		}
+
+		if cur.text.Len() == 0 || cfg != fg || cbg != bg {
+			flush()
+			fg, bg = cfg, cbg
+			cur.style = spanStyle(fg, bg)
+		}
+		cur.text.WriteString(escaper.Replace(string(glyph)))
+	}
+	flush()
+}
+
+// spanStyle returns the inline style for the given hex colors, where an empty string means no color.
+func spanStyle(fg, bg string) string {
+	var parts []string
+	if fg != "" {
+		parts = append(parts, "color:"+fg)
+	}
+	if bg != "" {
+		parts = append(parts, "background-color:"+bg)
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,62 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+func TestWriteLayer(t *testing.T) {
+	red, blue := xploader.Color{R: 0xff}, xploader.Color{B: 0xff}
+
+	layer := xploader.NewEmptyLayer(5, 2)
+	layer.Set(0, 0, xploader.Cell{Rune: '<', Fg: red, Bg: blue})
+	layer.Set(1, 0, xploader.Cell{Rune: '&', Fg: red, Bg: blue})
+	layer.Set(2, 0, xploader.Cell{Rune: ' ', Fg: blue, Bg: blue})
+	layer.Set(3, 0, xploader.Cell{Rune: '█', Fg: red, Bg: xploader.InvisibleColor})
+	layer.Set(4, 0, xploader.Cell{Rune: 'x', Fg: xploader.InvisibleColor, Bg: red})
+
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, layer, Options{}); err != nil {
+		t.Fatalf("Failed to write layer: %v", err)
+	}
+
+	want := `<pre style="font-family:monospace;line-height:1">` +
+		`<span style="color:#ff0000;background-color:#0000ff">&lt;&amp; </span>` +
+		`<span style="color:#ff0000">█</span>` +
+		`<span style="color:#ff0000;background-color:#ff0000"> </span>` + "\n" +
+		"     </pre>\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestWriteLayerOptions(t *testing.T) {
+	var buf bytes.Buffer
+	opts := Options{Class: `xp "preview"`, Style: "font-size:8px"}
+	if err := WriteLayer(&buf, xploader.NewEmptyLayer(1, 1), opts); err != nil {
+		t.Fatalf("Failed to write layer: %v", err)
+	}
+
+	if want := `<pre class="xp &#34;preview&#34;" style="font-size:8px">`; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("Got %q, want prefix %q", buf.String(), want)
+	}
+}
+
+func TestWriteXPFile(t *testing.T) {
+	xp, err := xploader.LoadXPFile("../../testdata/multilayer.xp")
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteXPFile(&buf, xp, Options{}); err != nil {
+		t.Fatalf("Failed to write XP file: %v", err)
+	}
+
+	if lines, want := strings.Count(buf.String(), "\n"), int(xp.Flatten().Height); lines != want {
+		t.Errorf("Got %d lines, want %d", lines, want)
+	}
+}
@@ -0,0 +1,330 @@
+package img
+
+import (
+	"image"
+	"math"
+
+	"github.com/malc0mn/xploder"
+)
+
+const (
+	// defaultCellSize is the size in pixels of the source block a cell covers when no layer dimensions are given.
+	defaultCellSize = 8
+
+	// shadePairCandidates limits the palette colors considered for shading glyphs to those nearest to a cell's color.
+	shadePairCandidates = 8
+)
+
+// ConvertOptions controls how images are converted to layers.
+type ConvertOptions struct {
+	// Width and Height are the dimensions of the layer in cells. When only one of them is set, the other follows from
+	// the aspect ratio of the image and CellAspect. When both are zero, every cell covers an 8x8 block of pixels.
+	Width, Height int
+
+	// CellAspect is the height of a cell divided by its width, used to keep the aspect ratio of the image when only
+	// Width or Height is set. Defaults to 1, matching square fonts such as the DefaultFont.
+	CellAspect float64
+
+	// Palette restricts the colors of the layer. When empty, any color can be used. Restricting the palette enables
+	// the shading glyphs ░, ▒ and ▓ to mix two palette colors.
+	Palette []xploader.Color
+
+	// Dither diffuses the color error of every cell to its neighbours using Floyd-Steinberg dithering, which improves
+	// gradients when the palette is restricted.
+	Dither bool
+}
+
+// rgb is a color with floating point components, used while accumulating and comparing colors.
+type rgb struct {
+	r, g, b float64
+}
+
+// add returns the sum of both colors.
+func (c rgb) add(o rgb) rgb {
+	return rgb{c.r + o.r, c.g + o.g, c.b + o.b}
+}
+
+// sub returns the difference of both colors.
+func (c rgb) sub(o rgb) rgb {
+	return rgb{c.r - o.r, c.g - o.g, c.b - o.b}
+}
+
+// mul returns the color scaled by f.
+func (c rgb) mul(f float64) rgb {
+	return rgb{c.r * f, c.g * f, c.b * f}
+}
+
+// dist returns the squared euclidean distance between both colors.
+func (c rgb) dist(o rgb) float64 {
+	d := c.sub(o)
+	return d.r*d.r + d.g*d.g + d.b*d.b
+}
+
+// color returns the nearest Color.
+func (c rgb) color() xploader.Color {
+	return xploader.Color{R: clampChannel(c.r), G: clampChannel(c.g), B: clampChannel(c.b)}
+}
+
+// clampChannel rounds a color component to the nearest valid value.
+func clampChannel(v float64) uint8 {
+	return uint8(math.Round(min(max(v, 0), 255)))
+}
+
+// toRGB converts a Color.
+func toRGB(c xploader.Color) rgb {
+	return rgb{float64(c.R), float64(c.G), float64(c.B)}
+}
+
+// mix returns a blend of f parts a and 1-f parts b.
+func mix(a, b rgb, f float64) rgb {
+	return a.mul(f).add(b.mul(1 - f))
+}
+
+// Quadrants of a cell, in the order they are sampled.
+const (
+	topLeft = iota
+	topRight
+	bottomLeft
+	bottomRight
+)
+
+// halfBlocks lists the half block glyphs with the quadrants their foreground covers.
+var halfBlocks = []struct {
+	glyph rune
+	fg    [2]int
+	bg    [2]int
+}{
+	{'▀', [2]int{topLeft, topRight}, [2]int{bottomLeft, bottomRight}},
+	{'▄', [2]int{bottomLeft, bottomRight}, [2]int{topLeft, topRight}},
+	{'▌', [2]int{topLeft, bottomLeft}, [2]int{topRight, bottomRight}},
+	{'▐', [2]int{topRight, bottomRight}, [2]int{topLeft, bottomLeft}},
+}
+
+// shades lists the shading glyphs with the fraction of the cell their foreground covers.
+var shades = []struct {
+	glyph    rune
+	coverage float64
+}{
+	{'░', 0.25},
+	{'▒', 0.5},
+	{'▓', 0.75},
+}
+
+// FromImage converts an image to a row-major layer, approximating every cell's block of pixels with the best fitting
+// glyph: a full block, one of the half blocks ▀, ▄, ▌ and ▐ or, with a restricted palette, one of the shades ░, ▒ and
+// ▓, along with the foreground and background colors. Cells covering mostly transparent pixels are left empty.
+func FromImage(src image.Image, opts ConvertOptions) *xploader.Layer {
+	b := src.Bounds()
+	width, height := layerSize(b.Dx(), b.Dy(), opts)
+	layer := xploader.NewEmptyLayer(width, height)
+	if b.Empty() {
+		return layer
+	}
+
+	c := converter{src: src, opts: opts}
+	var errCur, errNext []rgb
+	if opts.Dither {
+		errCur, errNext = make([]rgb, width), make([]rgb, width)
+	}
+
+	cw := float64(b.Dx()) / float64(width)
+	ch := float64(b.Dy()) / float64(height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			q, opaque := c.sample(b.Min.X, b.Min.Y, float64(x)*cw, float64(y)*ch, cw, ch)
+			if !opaque {
+				continue
+			}
+
+			if opts.Dither {
+				for i := range q {
+					q[i] = q[i].add(errCur[x])
+				}
+			}
+
+			cell, rendered := c.best(q)
+			layer.Set(x, y, cell)
+
+			if opts.Dither {
+				diffuse(errCur, errNext, x, mean(q[:]...).sub(rendered))
+			}
+		}
+		if opts.Dither {
+			errCur, errNext = errNext, errCur
+			clear(errNext)
+		}
+	}
+
+	return layer
+}
+
+// layerSize returns the dimensions of the layer an image of the given size converts to.
+func layerSize(w, h int, opts ConvertOptions) (int, int) {
+	aspect := opts.CellAspect
+	if aspect <= 0 {
+		aspect = 1
+	}
+
+	width, height := opts.Width, opts.Height
+	switch {
+	case width <= 0 && height <= 0:
+		width, height = w/defaultCellSize, h/defaultCellSize
+	case height <= 0:
+		height = int(math.Round(float64(width) * float64(h) / float64(w) / aspect))
+	case width <= 0:
+		width = int(math.Round(float64(height) * float64(w) / float64(h) * aspect))
+	}
+
+	return max(width, 1), max(height, 1)
+}
+
+// diffuse spreads the error of the cell at x over its unprocessed neighbours.
+func diffuse(cur, next []rgb, x int, e rgb) {
+	if x+1 < len(cur) {
+		cur[x+1] = cur[x+1].add(e.mul(7.0 / 16))
+		next[x+1] = next[x+1].add(e.mul(1.0 / 16))
+	}
+	if x > 0 {
+		next[x-1] = next[x-1].add(e.mul(3.0 / 16))
+	}
+	next[x] = next[x].add(e.mul(5.0 / 16))
+}
+
+// converter picks glyphs and colors for the cells of an image.
+type converter struct {
+	src  image.Image
+	opts ConvertOptions
+}
+
+// sample returns the average colors of the four quadrants of the cell at (x, y) sized w by h, in pixels relative to
+// the image origin (ox, oy). It reports false when the cell is mostly transparent.
+func (c *converter) sample(ox, oy int, x, y, w, h float64) ([4]rgb, bool) {
+	var q [4]rgb
+	var alpha float64
+
+	var sums [4]rgb
+	var weights [4]float64
+	for i := range q {
+		qx := x + float64(i%2)*w/2
+		qy := y + float64(i/2)*h/2
+		x0, y0 := int(math.Floor(qx)), int(math.Floor(qy))
+		x1, y1 := max(int(math.Ceil(qx+w/2)), x0+1), max(int(math.Ceil(qy+h/2)), y0+1)
+
+		n := 0
+		for py := y0; py < y1; py++ {
+			for px := x0; px < x1; px++ {
+				r, g, b, a := c.src.At(ox+px, oy+py).RGBA()
+				// The channels are premultiplied, so dividing the sums by the alpha sum yields the average visible color.
+				sums[i] = sums[i].add(rgb{float64(r), float64(g), float64(b)})
+				weights[i] += float64(a)
+				n++
+			}
+		}
+		alpha += weights[i] / float64(n) / 0xffff
+	}
+
+	if alpha < 2 {
+		return q, false
+	}
+
+	var total rgb
+	var totalWeight float64
+	for i := range sums {
+		total = total.add(sums[i])
+		totalWeight += weights[i]
+	}
+	for i := range q {
+		if weights[i] == 0 {
+			q[i] = total.mul(0xff / totalWeight)
+		} else {
+			q[i] = sums[i].mul(0xff / weights[i])
+		}
+	}
+
+	return q, true
+}
+
+// mean returns the average of the colors.
+func mean(colors ...rgb) rgb {
+	var sum rgb
+	for _, c := range colors {
+		sum = sum.add(c)
+	}
+	return sum.mul(1 / float64(len(colors)))
+}
+
+// quantize returns the palette color nearest to c, or c itself when the palette is not restricted.
+func (c *converter) quantize(v rgb) xploader.Color {
+	col := v.color()
+	if len(c.opts.Palette) == 0 {
+		return col
+	}
+	return c.opts.Palette[xploader.NearestPaletteIndex(col, c.opts.Palette)]
+}
+
+// best returns the cell that best approximates the quadrant colors, along with the average color it renders as.
+func (c *converter) best(q [4]rgb) (xploader.Cell, rgb) {
+	m := mean(q[:]...)
+
+	solid := c.quantize(m)
+	best := xploader.Cell{Rune: '█', Fg: solid, Bg: solid}
+	bestRendered := toRGB(solid)
+	bestErr := 0.0
+	for _, v := range q {
+		bestErr += v.dist(bestRendered)
+	}
+
+	for _, hb := range halfBlocks {
+		fg := c.quantize(mean(q[hb.fg[0]], q[hb.fg[1]]))
+		bg := c.quantize(mean(q[hb.bg[0]], q[hb.bg[1]]))
+		if fg == bg {
+			continue
+		}
+
+		f, g := toRGB(fg), toRGB(bg)
+		e := q[hb.fg[0]].dist(f) + q[hb.fg[1]].dist(f) + q[hb.bg[0]].dist(g) + q[hb.bg[1]].dist(g)
+		if e < bestErr {
+			best, bestErr, bestRendered = xploader.Cell{Rune: hb.glyph, Fg: fg, Bg: bg}, e, mix(f, g, 0.5)
+		}
+	}
+
+	if len(c.opts.Palette) < 2 {
+		return best, bestRendered
+	}
+
+	candidates := c.nearestColors(m.color(), shadePairCandidates)
+	for i, fg := range candidates {
+		for j, bg := range candidates {
+			if i == j {
+				continue
+			}
+			f, g := toRGB(fg), toRGB(bg)
+			for _, s := range shades {
+				rendered := mix(f, g, s.coverage)
+				e := 0.0
+				for _, v := range q {
+					e += v.dist(rendered)
+				}
+				if e < bestErr {
+					best, bestErr, bestRendered = xploader.Cell{Rune: s.glyph, Fg: fg, Bg: bg}, e, rendered
+				}
+			}
+		}
+	}
+
+	return best, bestRendered
+}
+
+// nearestColors returns up to n palette colors, nearest to c first.
+func (c *converter) nearestColors(col xploader.Color, n int) []xploader.Color {
+	rest := append([]xploader.Color(nil), c.opts.Palette...)
+	n = min(n, len(rest))
+
+	out := make([]xploader.Color, 0, n)
+	for range n {
+		i := xploader.NearestPaletteIndex(col, rest)
+		out = append(out, rest[i])
+		rest = append(rest[:i], rest[i+1:]...)
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+func uniformImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestFromImageSolid(t *testing.T) {
+	layer := FromImage(uniformImage(16, 24, color.RGBA{R: 10, G: 20, B: 30, A: 0xff}), ConvertOptions{})
+	if layer.Width != 2 || layer.Height != 3 {
+		t.Fatalf("Dimensions: got %dx%d, want 2x3", layer.Width, layer.Height)
+	}
+
+	c := xploader.Color{R: 10, G: 20, B: 30}
+	want := xploader.Cell{Rune: '█', Fg: c, Bg: c}
+	for i, cell := range layer.Cells {
+		if cell != want {
+			t.Fatalf("Cell %d: got %+v, want %+v", i, cell, want)
+		}
+	}
+}
+
+func TestFromImageHalfBlocks(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+
+	r, b := xploader.Color{R: 0xff}, xploader.Color{B: 0xff}
+
+	// A half block and its opposite with swapped colors look the same, the first one listed is preferred.
+	tests := []struct {
+		name  string
+		split image.Rectangle
+		want  xploader.Cell
+	}{
+		{"top", image.Rect(0, 0, 8, 4), xploader.Cell{Rune: '▀', Fg: r, Bg: b}},
+		{"bottom", image.Rect(0, 4, 8, 8), xploader.Cell{Rune: '▀', Fg: b, Bg: r}},
+		{"left", image.Rect(0, 0, 4, 8), xploader.Cell{Rune: '▌', Fg: r, Bg: b}},
+		{"right", image.Rect(4, 0, 8, 8), xploader.Cell{Rune: '▌', Fg: b, Bg: r}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := uniformImage(8, 8, blue)
+			draw.Draw(img, tt.split, image.NewUniform(red), image.Point{}, draw.Src)
+
+			if got := FromImage(img, ConvertOptions{}).At(0, 0); got != tt.want {
+				t.Errorf("Got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromImagePaletteShades(t *testing.T) {
+	black, white := xploader.Color{}, xploader.Color{R: 0xff, G: 0xff, B: 0xff}
+	opts := ConvertOptions{Palette: []xploader.Color{black, white}}
+
+	got := FromImage(uniformImage(8, 8, color.Gray{Y: 0x80}), opts).At(0, 0)
+	if want := (xploader.Cell{Rune: '▒', Fg: white, Bg: black}); got != want {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+}
+
+func TestFromImageTransparent(t *testing.T) {
+	layer := FromImage(image.NewRGBA(image.Rect(0, 0, 16, 8)), ConvertOptions{})
+	for i, cell := range layer.Cells {
+		if !cell.IsEmpty() {
+			t.Errorf("Cell %d: got %+v, want an empty cell", i, cell)
+		}
+	}
+}
+
+func TestFromImageDither(t *testing.T) {
+	src := uniformImage(80, 80, color.Gray{Y: 20})
+	palette := []xploader.Color{{}, {R: 0xff, G: 0xff, B: 0xff}}
+
+	brightness := func(layer *xploader.Layer) float64 {
+		coverage := map[rune]float64{'░': 0.25, '▒': 0.5, '▓': 0.75, '█': 1}
+		sum := 0.0
+		for _, cell := range layer.Cells {
+			cov := coverage[cell.Rune]
+			sum += cov*float64(cell.Fg.R) + (1-cov)*float64(cell.Bg.R)
+		}
+		return sum / float64(len(layer.Cells))
+	}
+
+	plain := brightness(FromImage(src, ConvertOptions{Palette: palette}))
+	dithered := brightness(FromImage(src, ConvertOptions{Palette: palette, Dither: true}))
+	if plain != 0 {
+		t.Errorf("Without dithering: got brightness %.2f, want 0", plain)
+	}
+	if dithered < 15 || dithered > 25 {
+		t.Errorf("With dithering: got brightness %.2f, want about 20", dithered)
+	}
+}
+
+func TestLayerSize(t *testing.T) {
+	tests := []struct {
+		w, h          int
+		opts          ConvertOptions
+		width, height int
+	}{
+		{64, 32, ConvertOptions{}, 8, 4},
+		{4, 4, ConvertOptions{}, 1, 1},
+		{64, 32, ConvertOptions{Width: 20}, 20, 10},
+		{64, 32, ConvertOptions{Height: 10}, 20, 10},
+		{64, 32, ConvertOptions{Width: 20, CellAspect: 2}, 20, 5},
+		{64, 32, ConvertOptions{Width: 5, Height: 7}, 5, 7},
+	}
+
+	for _, tt := range tests {
+		if w, h := layerSize(tt.w, tt.h, tt.opts); w != tt.width || h != tt.height {
+			t.Errorf("%dx%d with %+v: got %dx%d, want %dx%d", tt.w, tt.h, tt.opts, w, h, tt.width, tt.height)
+		}
+	}
+}
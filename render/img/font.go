@@ -0,0 +1,101 @@
+package img
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+)
+
+// GlyphsPerRow is the number of glyphs per row, and the number of rows, of a REXPaint style glyph sheet.
+const GlyphsPerRow = 16
+
+// Font is a CP437 bitmap font loaded from a REXPaint style glyph sheet: a 16x16 grid of glyphs ordered by CP437 code.
+type Font struct {
+	// Sheet is the glyph sheet the font was loaded from.
+	Sheet image.Image
+
+	// GlyphWidth and GlyphHeight are the dimensions of a single glyph in pixels.
+	GlyphWidth, GlyphHeight int
+
+	// coverage holds, for every glyph, how much of each pixel the glyph covers, from 0 to 255, row by row.
+	coverage [256][]uint8
+}
+
+// NewFont loads a font from a glyph sheet whose dimensions are multiples of 16. Glyph pixels are expected to be
+// white, or gray for anti-aliased edges, on a black, transparent or magenta background, as in the sheets shipped with
+// REXPaint: the brightness of a pixel determines how much of the foreground color it shows, and magenta pixels are
+// always background.
+func NewFont(sheet image.Image) (*Font, error) {
+	b := sheet.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 || b.Dx()%GlyphsPerRow != 0 || b.Dy()%GlyphsPerRow != 0 {
+		return nil, fmt.Errorf("glyph sheet dimensions %dx%d are not a non-zero multiple of %d", b.Dx(), b.Dy(), GlyphsPerRow)
+	}
+
+	f := &Font{
+		Sheet:       sheet,
+		GlyphWidth:  b.Dx() / GlyphsPerRow,
+		GlyphHeight: b.Dy() / GlyphsPerRow,
+	}
+
+	for code := range f.coverage {
+		ox := b.Min.X + code%GlyphsPerRow*f.GlyphWidth
+		oy := b.Min.Y + code/GlyphsPerRow*f.GlyphHeight
+
+		cov := make([]uint8, 0, f.GlyphWidth*f.GlyphHeight)
+		for y := 0; y < f.GlyphHeight; y++ {
+			for x := 0; x < f.GlyphWidth; x++ {
+				cov = append(cov, pixelCoverage(sheet.At(ox+x, oy+y)))
+			}
+		}
+		f.coverage[code] = cov
+	}
+
+	return f, nil
+}
+
+// pixelCoverage returns how much of the foreground color a glyph sheet pixel shows.
+func pixelCoverage(c color.Color) uint8 {
+	r, g, b, a := c.RGBA()
+	if a == 0xffff && r == 0xffff && g == 0 && b == 0xffff {
+		return 0
+	}
+	// The channels are premultiplied by alpha, so this accounts for transparent backgrounds as well.
+	return uint8(max(r, g, b) >> 8)
+}
+
+// Coverage returns how much of the pixel at (x, y) of the glyph with the given CP437 code is covered by the glyph,
+// from 0 (background) to 255 (foreground).
+func (f *Font) Coverage(code, x, y int) uint8 {
+	return f.coverage[code][y*f.GlyphWidth+x]
+}
+
+var (
+	defaultFont     *Font
+	defaultFontOnce sync.Once
+)
+
+// DefaultFont returns the built-in 8x8 CP437 font, the IBM PC BIOS font, as white glyphs on a black background.
+func DefaultFont() *Font {
+	defaultFontOnce.Do(func() {
+		sheet := image.NewGray(image.Rect(0, 0, GlyphsPerRow*8, GlyphsPerRow*8))
+		for code, rows := range font8x8 {
+			ox, oy := code%GlyphsPerRow*8, code/GlyphsPerRow*8
+			for y, bits := range rows {
+				for x := 0; x < 8; x++ {
+					if bits&(0x80>>x) != 0 {
+						sheet.SetGray(ox+x, oy+y, color.Gray{Y: 0xff})
+					}
+				}
+			}
+		}
+
+		f, err := NewFont(sheet)
+		if err != nil {
+			panic(err)
+		}
+		defaultFont = f
+	})
+
+	return defaultFont
+}
@@ -0,0 +1,65 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDefaultFont(t *testing.T) {
+	f := DefaultFont()
+	if f.GlyphWidth != 8 || f.GlyphHeight != 8 {
+		t.Fatalf("Glyph size: got %dx%d, want 8x8", f.GlyphWidth, f.GlyphHeight)
+	}
+
+	// The full block covers every pixel, the space none.
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if c := f.Coverage(0xDB, x, y); c != 0xff {
+				t.Fatalf("Full block (%d,%d): got coverage %d", x, y, c)
+			}
+			if c := f.Coverage(' ', x, y); c != 0 {
+				t.Fatalf("Space (%d,%d): got coverage %d", x, y, c)
+			}
+		}
+	}
+}
+
+func TestNewFontInvalidSheet(t *testing.T) {
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 0, 0), image.Rect(0, 0, 100, 128), image.Rect(0, 0, 128, 20)} {
+		if _, err := NewFont(image.NewGray(r)); err == nil {
+			t.Errorf("Expected an error for a %dx%d sheet", r.Dx(), r.Dy())
+		}
+	}
+}
+
+func TestNewFontCoverage(t *testing.T) {
+	sheet := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	sheet.Set(2, 0, color.RGBA{R: 0xff, B: 0xff, A: 0xff}) // Magenta key color.
+	sheet.Set(3, 0, color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff})
+	sheet.Set(4, 0, color.RGBA{R: 0x40, G: 0x40, B: 0x40, A: 0x40}) // Premultiplied white at 25% alpha.
+	sheet.Set(5, 0, color.White)
+
+	f, err := NewFont(sheet)
+	if err != nil {
+		t.Fatalf("Failed to load font: %v", err)
+	}
+	if f.GlyphWidth != 2 || f.GlyphHeight != 2 {
+		t.Fatalf("Glyph size: got %dx%d, want 2x2", f.GlyphWidth, f.GlyphHeight)
+	}
+
+	tests := []struct {
+		code, x int
+		want    uint8
+	}{
+		{1, 0, 0},
+		{1, 1, 0x80},
+		{2, 0, 0x40},
+		{2, 1, 0xff},
+	}
+	for _, tt := range tests {
+		if got := f.Coverage(tt.code, tt.x, 0); got != tt.want {
+			t.Errorf("Glyph %d pixel %d: got coverage %d, want %d", tt.code, tt.x, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package img renders REXPaint layers to images using CP437 bitmap fonts, and converts images to layers.
+package img
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/malc0mn/xploder"
+)
+
+// RenderXPFile flattens the XPFile, skipping hidden layers, and renders the result using the given font, or the
+// DefaultFont when font is nil.
+func RenderXPFile(xp *xploader.XPFile, font *Font) *image.RGBA {
+	return RenderLayer(xp.Flatten(), font)
+}
+
+// RenderLayer renders the layer using the given font, or the DefaultFont when font is nil. Every cell becomes a glyph
+// sized block of pixels showing the glyph in the foreground color on the background color. Cells with an
+// InvisibleColor background are transparent around the glyph, and glyphs with an InvisibleColor foreground are not
+// drawn, leaving them fully transparent when both colors are invisible.
+func RenderLayer(layer *xploader.Layer, font *Font) *image.RGBA {
+	if font == nil {
+		font = DefaultFont()
+	}
+
+	gw, gh := font.GlyphWidth, font.GlyphHeight
+	img := image.NewRGBA(image.Rect(0, 0, int(layer.Width)*gw, int(layer.Height)*gh))
+
+	for y := 0; y < int(layer.Height); y++ {
+		for x := 0; x < int(layer.Width); x++ {
+			cell := layer.At(x, y)
+			code := int(xploader.CP437ReplacingEncoder(cell.Rune))
+
+			for py := 0; py < gh; py++ {
+				for px := 0; px < gw; px++ {
+					cov := font.Coverage(code, px, py)
+					img.SetRGBA(x*gw+px, y*gh+py, shade(cell, cov))
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// shade returns the color of a cell pixel that is covered by the glyph for cov/255.
+func shade(cell xploader.Cell, cov uint8) color.RGBA {
+	if cell.Fg.IsInvisible() {
+		cov = 0
+	}
+
+	fg := color.RGBA{R: cell.Fg.R, G: cell.Fg.G, B: cell.Fg.B, A: 0xff}
+	if cell.Bg.IsInvisible() {
+		// Premultiplied foreground over nothing.
+		return color.RGBA{R: scale(fg.R, cov), G: scale(fg.G, cov), B: scale(fg.B, cov), A: cov}
+	}
+
+	bg := color.RGBA{R: cell.Bg.R, G: cell.Bg.G, B: cell.Bg.B, A: 0xff}
+	switch cov {
+	case 0:
+		return bg
+	case 0xff:
+		return fg
+	}
+	return color.RGBA{
+		R: scale(fg.R, cov) + scale(bg.R, 0xff-cov),
+		G: scale(fg.G, cov) + scale(bg.G, 0xff-cov),
+		B: scale(fg.B, cov) + scale(bg.B, 0xff-cov),
+		A: 0xff,
+	}
+}
+
+// scale returns v scaled by f/255.
+func scale(v, f uint8) uint8 {
+	return uint8((uint16(v)*uint16(f) + 127) / 255)
+}
+
+// WritePNG renders the XPFile, see RenderXPFile, and writes it to w as a PNG image.
+func WritePNG(w io.Writer, xp *xploader.XPFile, font *Font) error {
+	if err := png.Encode(w, RenderXPFile(xp, font)); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return nil
+}
+
+// SavePNG renders the XPFile, see RenderXPFile, and saves it as a PNG image at the given path.
+func SavePNG(path string, xp *xploader.XPFile, font *Font) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if err := WritePNG(f, xp, font); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package img
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+var (
+	red  = xploader.Color{R: 0xff}
+	blue = xploader.Color{B: 0xff}
+)
+
+func TestRenderLayer(t *testing.T) {
+	layer := xploader.NewEmptyLayer(3, 1)
+	layer.Set(0, 0, xploader.Cell{Rune: '▀', Fg: red, Bg: blue})
+	layer.Set(1, 0, xploader.Cell{Rune: '▀', Fg: red, Bg: xploader.InvisibleColor})
+	layer.Set(2, 0, xploader.Cell{Rune: '▀', Fg: xploader.InvisibleColor, Bg: blue})
+
+	img := RenderLayer(layer, nil)
+	if b := img.Bounds(); b.Dx() != 24 || b.Dy() != 8 {
+		t.Fatalf("Image size: got %dx%d, want 24x8", b.Dx(), b.Dy())
+	}
+
+	opaqueRed := color.RGBA{R: 0xff, A: 0xff}
+	opaqueBlue := color.RGBA{B: 0xff, A: 0xff}
+	tests := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 0, opaqueRed},
+		{7, 7, opaqueBlue},
+		{8, 0, opaqueRed},
+		{15, 7, color.RGBA{}},
+		{16, 0, opaqueBlue},
+		{23, 7, opaqueBlue},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("Pixel (%d,%d): got %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestRenderLayerUnmappableRune(t *testing.T) {
+	// The currency sign lies below 256 but has no CP437 equivalent.
+	layer := xploader.NewEmptyLayer(3, 1)
+	layer.Set(0, 0, xploader.Cell{Rune: '?', Fg: red, Bg: blue})
+	layer.Set(1, 0, xploader.Cell{Rune: '😀', Fg: red, Bg: blue})
+	layer.Set(2, 0, xploader.Cell{Rune: '¤', Fg: red, Bg: blue})
+
+	img := RenderLayer(layer, nil)
+	for cx := 1; cx < 3; cx++ {
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				if a, b := img.RGBAAt(x, y), img.RGBAAt(cx*8+x, y); a != b {
+					t.Fatalf("Pixel (%d,%d): got %v, want the fallback glyph's %v", cx*8+x, y, b, a)
+				}
+			}
+		}
+	}
+}
+
+func TestRenderLayerBlendsCoverage(t *testing.T) {
+	if got, want := shade(xploader.Cell{Rune: 'x', Fg: red, Bg: blue}, 0x80), (color.RGBA{R: 0x80, B: 0x7f, A: 0xff}); got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	xp, err := xploader.LoadXPFile("../../testdata/multilayer.xp")
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, xp, nil); err != nil {
+		t.Fatalf("Failed to write PNG: %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+
+	flat := xp.Flatten()
+	if b := img.Bounds(); b.Dx() != int(flat.Width)*8 || b.Dy() != int(flat.Height)*8 {
+		t.Errorf("Image size: got %dx%d, want %dx%d", b.Dx(), b.Dy(), flat.Width*8, flat.Height*8)
+	}
+}
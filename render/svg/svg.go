@@ -0,0 +1,230 @@
+// Package svg renders REXPaint layers as standalone, scalable SVG images.
+package svg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/malc0mn/xploder"
+	"github.com/malc0mn/xploder/render/img"
+)
+
+// GlyphMode selects how glyphs are drawn.
+type GlyphMode int
+
+const (
+	// Paths draws glyphs as vector paths traced from the pixels of a bitmap font. The result looks the same
+	// everywhere, without depending on the fonts installed on the viewer's system.
+	Paths GlyphMode = iota
+	// Text draws glyphs as text elements using the configured font family.
+	Text
+)
+
+// defaultFontFamily is the font family of text glyphs when none is configured.
+const defaultFontFamily = "monospace"
+
+// Options controls how layers are rendered.
+type Options struct {
+	// Mode selects how glyphs are drawn. Defaults to Paths.
+	Mode GlyphMode
+
+	// Font is the bitmap font glyph paths are traced from. Its glyph size also determines the size of a cell in SVG
+	// units, in both modes. Defaults to img.DefaultFont.
+	Font *img.Font
+
+	// FontFamily is the font family of text glyphs. Defaults to monospace.
+	FontFamily string
+
+	// Scale multiplies the width and height of the image, which keeps its view box. Defaults to 1.
+	Scale float64
+}
+
+// escaper escapes the characters that are special in XML text and attributes.
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&#34;", "'", "&#39;")
+
+// WriteXPFile flattens the XPFile, following REXPaint's compositing rules and skipping hidden layers, and renders the
+// result to w.
+func WriteXPFile(w io.Writer, xp *xploader.XPFile, opts Options) error {
+	return WriteLayer(w, xp.Flatten(), opts)
+}
+
+// WriteLayer renders the layer to w as a standalone SVG document. Cell backgrounds become rectangles, merged into a
+// single rectangle for runs of the same color, and glyphs become paths or text depending on opts.Mode. Cells with an
+// InvisibleColor background get no rectangle, so the image is transparent there, and glyphs with an InvisibleColor
+// foreground are not drawn.
+func WriteLayer(w io.Writer, layer *xploader.Layer, opts Options) error {
+	font := opts.Font
+	if font == nil {
+		font = img.DefaultFont()
+	}
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	r := renderer{
+		layer: layer,
+		font:  font,
+		gw:    font.GlyphWidth,
+		gh:    font.GlyphHeight,
+	}
+	width, height := int(layer.Width)*r.gw, int(layer.Height)*r.gh
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		formatFloat(float64(width)*scale), formatFloat(float64(height)*scale), width, height)
+
+	r.backgrounds(bw)
+	if opts.Mode == Text {
+		family := opts.FontFamily
+		if family == "" {
+			family = defaultFontFamily
+		}
+		r.text(bw, family)
+	} else {
+		r.paths(bw)
+	}
+
+	bw.WriteString("</svg>\n")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write layer: %w", err)
+	}
+	return nil
+}
+
+// renderer writes the elements of an SVG document for a layer.
+type renderer struct {
+	layer  *xploader.Layer
+	font   *img.Font
+	gw, gh int
+}
+
+// backgrounds writes a rectangle for every horizontal run of cells with the same visible background color.
+func (r *renderer) backgrounds(w *bufio.Writer) {
+	for y := 0; y < int(r.layer.Height); y++ {
+		for x := 0; x < int(r.layer.Width); {
+			bg := r.layer.At(x, y).Bg
+			n := 1
+			for x+n < int(r.layer.Width) && r.layer.At(x+n, y).Bg == bg {
+				n++
+			}
+			if !bg.IsInvisible() {
+				fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x*r.gw, y*r.gh, n*r.gw, r.gh, bg.Hex())
+			}
+			x += n
+		}
+	}
+}
+
+// paths writes a single path per foreground color, tracing every glyph drawn in that color from the font's pixels.
+func (r *renderer) paths(w *bufio.Writer) {
+	var order []xploader.Color
+	paths := map[xploader.Color]*strings.Builder{}
+
+	for y := 0; y < int(r.layer.Height); y++ {
+		for x := 0; x < int(r.layer.Width); x++ {
+			cell := r.layer.At(x, y)
+			if !cell.HasVisibleGlyph() {
+				continue
+			}
+
+			sb, ok := paths[cell.Fg]
+			if !ok {
+				sb = &strings.Builder{}
+				paths[cell.Fg] = sb
+				order = append(order, cell.Fg)
+			}
+			r.tracePath(sb, int(xploader.CP437ReplacingEncoder(cell.Rune)), x*r.gw, y*r.gh)
+		}
+	}
+
+	for _, c := range order {
+		if d := paths[c].String(); d != "" {
+			fmt.Fprintf(w, `<path fill="%s" d="%s"/>`+"\n", c.Hex(), d)
+		}
+	}
+}
+
+// tracePath appends the outline of the glyph at (ox, oy) to the path data, as one rectangle per horizontal run of
+// pixels the glyph covers for at least half.
+func (r *renderer) tracePath(sb *strings.Builder, code, ox, oy int) {
+	for py := 0; py < r.gh; py++ {
+		for px := 0; px < r.gw; {
+			if r.font.Coverage(code, px, py) < 0x80 {
+				px++
+				continue
+			}
+			n := 1
+			for px+n < r.gw && r.font.Coverage(code, px+n, py) >= 0x80 {
+				n++
+			}
+			fmt.Fprintf(sb, "M%d %dh%dv1h-%dz", ox+px, oy+py, n, n)
+			px += n
+		}
+	}
+}
+
+// text writes a text element per horizontal run of visible glyphs with the same foreground color, positioning every
+// glyph at the center of its cell.
+func (r *renderer) text(w *bufio.Writer, family string) {
+	fmt.Fprintf(w, `<g font-family="%s" font-size="%d" text-anchor="middle" dominant-baseline="central">`+"\n",
+		escaper.Replace(family), r.gh)
+
+	for y := 0; y < int(r.layer.Height); y++ {
+		for x := 0; x < int(r.layer.Width); {
+			cell := r.layer.At(x, y)
+			if !cell.HasVisibleGlyph() {
+				x++
+				continue
+			}
+
+			var xs []string
+			var glyphs strings.Builder
+			for ; x < int(r.layer.Width); x++ {
+				c := r.layer.At(x, y)
+				if !c.HasVisibleGlyph() || c.Fg != cell.Fg {
+					break
+				}
+				xs = append(xs, formatFloat(float64(x*r.gw)+float64(r.gw)/2))
+				glyphs.WriteString(escaper.Replace(string(textGlyph(c.Rune))))
+			}
+
+			fmt.Fprintf(w, `<text x="%s" y="%s" fill="%s">%s</text>`+"\n",
+				strings.Join(xs, " "), formatFloat(float64(y*r.gh)+float64(r.gh)/2), cell.Fg.Hex(), glyphs.String())
+		}
+	}
+
+	w.WriteString("</g>\n")
+}
+
+// textGlyph returns the rune written for a glyph in text mode. Control characters, such as the raw CP437 codes kept when
+// loading without a RuneDecoder, are decoded to their CP437 glyph; runes that still cannot appear in an XML document
+// fall back to a question mark.
+func textGlyph(r rune) rune {
+	if unicode.IsControl(r) {
+		r = xploader.CP437Decoder(r)
+	}
+	if !isXMLChar(r) || unicode.IsControl(r) {
+		return '?'
+	}
+	return r
+}
+
+// isXMLChar reports whether the rune is allowed in an XML 1.0 document.
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xd7ff ||
+		r >= 0xe000 && r <= 0xfffd ||
+		r >= 0x10000 && r <= utf8.MaxRune
+}
+
+// formatFloat formats a float without superfluous decimals.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
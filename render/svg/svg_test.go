@@ -0,0 +1,123 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/malc0mn/xploder"
+)
+
+var (
+	red  = xploader.Color{R: 0xff}
+	blue = xploader.Color{B: 0xff}
+)
+
+func testLayer() *xploader.Layer {
+	layer := xploader.NewEmptyLayer(4, 1)
+	layer.Set(0, 0, xploader.Cell{Rune: '▀', Fg: red, Bg: blue})
+	layer.Set(1, 0, xploader.Cell{Rune: '<', Fg: red, Bg: blue})
+	layer.Set(2, 0, xploader.Cell{Rune: 'x', Fg: xploader.InvisibleColor, Bg: blue})
+	layer.Set(3, 0, xploader.Cell{Rune: '█', Fg: blue, Bg: xploader.InvisibleColor})
+	return layer
+}
+
+func TestWriteLayerPaths(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, testLayer(), Options{Scale: 2}); err != nil {
+		t.Fatalf("Failed to write layer: %v", err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		`width="64" height="16" viewBox="0 0 32 8"`,
+		`<rect x="0" y="0" width="24" height="8" fill="#0000ff"/>`,
+		// The upper half block traced as four runs of eight pixels.
+		`<path fill="#ff0000" d="M0 0h8v1h-8zM0 1h8v1h-8zM0 2h8v1h-8zM0 3h8v1h-8z`,
+		`<path fill="#0000ff" d="M24 0h8v1h-8z`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Output does not contain %q:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "<rect"); n != 1 {
+		t.Errorf("Got %d rects, want 1", n)
+	}
+	if strings.Contains(got, `M16 `) {
+		t.Error("The glyph with an invisible foreground should not be drawn")
+	}
+}
+
+func TestWriteLayerText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, testLayer(), Options{Mode: Text, FontFamily: "Px437 IBM VGA8"}); err != nil {
+		t.Fatalf("Failed to write layer: %v", err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		`<g font-family="Px437 IBM VGA8" font-size="8"`,
+		`<text x="4 12" y="4" fill="#ff0000">▀&lt;</text>`,
+		`<text x="28" y="4" fill="#0000ff">█</text>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteLayerTextRawCodes(t *testing.T) {
+	// A layer loaded without a RuneDecoder keeps raw CP437 codes, which are control characters as runes.
+	layer := xploader.NewEmptyLayer(33, 1)
+	for x := 0; x < 32; x++ {
+		layer.Set(x, 0, xploader.Cell{Rune: rune(x + 1), Fg: red, Bg: blue})
+	}
+	layer.Set(32, 0, xploader.Cell{Rune: 0xfffe, Fg: red, Bg: blue})
+
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, layer, Options{Mode: Text}); err != nil {
+		t.Fatalf("Failed to write layer: %v", err)
+	}
+	got := buf.String()
+
+	if !strings.Contains(got, ">☺☻♥♦") || !strings.Contains(got, "▼</text>") || !strings.Contains(got, ">?</text>") {
+		t.Errorf("Raw codes were not decoded:\n%s", got)
+	}
+
+	d := xml.NewDecoder(&buf)
+	for {
+		if _, err := d.Token(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("Invalid XML: %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestWriteXPFileIsValidXML(t *testing.T) {
+	xp, err := xploader.LoadXPFile("../../testdata/allchars.xp")
+	if err != nil {
+		t.Fatalf("Failed to load XP file: %v", err)
+	}
+
+	for _, mode := range []GlyphMode{Paths, Text} {
+		var buf bytes.Buffer
+		if err := WriteXPFile(&buf, xp, Options{Mode: mode}); err != nil {
+			t.Fatalf("Failed to write XP file: %v", err)
+		}
+
+		d := xml.NewDecoder(&buf)
+		for {
+			if _, err := d.Token(); err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("Mode %d: invalid XML: %v", mode, err)
+				}
+				break
+			}
+		}
+	}
+}
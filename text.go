@@ -0,0 +1,185 @@
+package xploader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultTabWidth is the distance between tab stops when building a layer from text.
+const defaultTabWidth = 8
+
+// ErrUnmappableRune is returned by LayerFromTextWithOptions when the text contains a character without a CP437
+// equivalent and the UnmappableError policy is in effect.
+var ErrUnmappableRune = errors.New("character has no CP437 equivalent")
+
+// UnmappablePolicy decides what happens to characters without a CP437 equivalent when building a layer from text.
+type UnmappablePolicy int
+
+const (
+	// UnmappableReplace replaces the character with TextOptions.Replacement.
+	UnmappableReplace UnmappablePolicy = iota
+	// UnmappableKeep keeps the character as is. Note that it can't be saved to a REXPaint compatible .xp file.
+	UnmappableKeep
+	// UnmappableSkip drops the character, moving the rest of the line one cell to the left.
+	UnmappableSkip
+	// UnmappableError fails with ErrUnmappableRune.
+	UnmappableError
+)
+
+// TextOptions controls the conversion of layers to and from plain text.
+type TextOptions struct {
+	// KeepTrailingSpaces keeps the spaces at the end of every line when converting a layer to text. By default they
+	// are trimmed.
+	KeepTrailingSpaces bool
+
+	// TabWidth is the distance between tab stops when building a layer from text: a tab advances to the next
+	// multiple of TabWidth. Defaults to 8.
+	TabWidth int
+
+	// Width is the width of the layer built from text. Shorter lines are padded with spaces and longer lines are
+	// truncated. Defaults to the length of the longest line.
+	Width int
+
+	// Unmappable decides what happens to characters without a CP437 equivalent when building a layer from text.
+	Unmappable UnmappablePolicy
+
+	// Replacement replaces unmappable characters under the UnmappableReplace policy. Defaults to '?'.
+	Replacement rune
+}
+
+// Text returns the glyphs of the layer as UTF-8 text, one line per row with trailing spaces trimmed. Colors are
+// discarded and glyphs with an InvisibleColor foreground are written as spaces.
+func (l *Layer) Text() string {
+	return l.TextWithOptions(TextOptions{})
+}
+
+// TextWithOptions returns the glyphs of the layer as UTF-8 text, one line per row. Colors are discarded and glyphs with
+// an InvisibleColor foreground are written as spaces.
+func (l *Layer) TextWithOptions(opts TextOptions) string {
+	var sb strings.Builder
+	line := make([]rune, l.Width)
+
+	for y := 0; y < int(l.Height); y++ {
+		for x := range line {
+			cell := l.At(x, y)
+			if cell.HasVisibleGlyph() {
+				line[x] = cell.Rune
+			} else {
+				line[x] = ' '
+			}
+		}
+
+		s := string(line)
+		if !opts.KeepTrailingSpaces {
+			s = strings.TrimRight(s, " ")
+		}
+		sb.WriteString(s)
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// LayerFromText builds a row-major layer from UTF-8 text, one row per line, using the given colors for every cell.
+// Tabs are expanded to tab stops every 8 cells, lines are padded to the length of the longest line and characters
+// without a CP437 equivalent are replaced with a question mark.
+func LayerFromText(r io.Reader, fg, bg Color) (*Layer, error) {
+	return LayerFromTextWithOptions(r, fg, bg, TextOptions{})
+}
+
+// LayerFromTextWithOptions builds a row-major layer from UTF-8 text, one row per line, using the given colors for
+// every cell. Both "\n" and "\r\n" line endings are accepted.
+func LayerFromTextWithOptions(r io.Reader, fg, bg Color, opts TextOptions) (*Layer, error) {
+	tabWidth := opts.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = defaultTabWidth
+	}
+	replacement := opts.Replacement
+	if replacement == 0 {
+		replacement = '?'
+	}
+
+	var lines [][]rune
+	width := 0
+
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		s, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read text: %w", err)
+		}
+		if s == "" && err == io.EOF {
+			break
+		}
+
+		s = strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+		line, lineErr := textLine(s, tabWidth, opts.Unmappable, replacement)
+		if lineErr != nil {
+			return nil, fmt.Errorf("line %d: %w", n, lineErr)
+		}
+		if opts.Width > 0 && len(line) > opts.Width {
+			line = line[:opts.Width]
+		}
+
+		lines = append(lines, line)
+		width = max(width, len(line))
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if opts.Width > 0 {
+		width = opts.Width
+	}
+	if err := checkDimensions(uint32(width), uint32(len(lines))); err != nil {
+		return nil, err
+	}
+
+	layer := NewEmptyLayer(width, len(lines))
+	blank := Cell{Rune: DefaultChar, Fg: fg, Bg: bg}
+	for y, line := range lines {
+		for x := 0; x < width; x++ {
+			cell := blank
+			if x < len(line) {
+				cell.Rune = line[x]
+			}
+			layer.Set(x, y, cell)
+		}
+	}
+
+	return layer, nil
+}
+
+// textLine converts a line of text to glyphs, expanding tabs and applying the unmappable character policy.
+func textLine(s string, tabWidth int, policy UnmappablePolicy, replacement rune) ([]rune, error) {
+	line := make([]rune, 0, len(s))
+
+	for _, r := range s {
+		if r == '\t' {
+			for n := tabWidth - len(line)%tabWidth; n > 0; n-- {
+				line = append(line, ' ')
+			}
+			continue
+		}
+
+		if _, ok := UnicodeToCP437[r]; !ok {
+			switch policy {
+			case UnmappableKeep:
+			case UnmappableSkip:
+				continue
+			case UnmappableError:
+				return nil, fmt.Errorf("column %d: %w: %q", len(line)+1, ErrUnmappableRune, r)
+			default:
+				r = replacement
+			}
+		}
+
+		line = append(line, r)
+	}
+
+	return line, nil
+}
@@ -0,0 +1,99 @@
+package xploader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLayerText(t *testing.T) {
+	layer := NewEmptyLayer(4, 3)
+	layer.Set(0, 0, Cell{Rune: '╔', Fg: Color{R: 255}, Bg: InvisibleColor})
+	layer.Set(1, 0, Cell{Rune: 'x', Fg: InvisibleColor, Bg: Color{}})
+	layer.Set(2, 0, Cell{Rune: '░', Fg: Color{}, Bg: Color{}})
+	layer.Set(1, 2, Cell{Rune: 0, Fg: Color{}, Bg: Color{}})
+
+	if got, want := layer.Text(), "╔ ░\n\n\n"; got != want {
+		t.Errorf("Text: got %q, want %q", got, want)
+	}
+
+	got := layer.TextWithOptions(TextOptions{KeepTrailingSpaces: true})
+	if want := "╔ ░ \n    \n    \n"; got != want {
+		t.Errorf("TextWithOptions: got %q, want %q", got, want)
+	}
+}
+
+func TestLayerFromText(t *testing.T) {
+	fg, bg := Color{R: 255}, Color{B: 255}
+
+	layer, err := LayerFromText(strings.NewReader("ab\tc\r\n╔═╗\n¤"), fg, bg)
+	if err != nil {
+		t.Fatalf("Failed to build layer: %v", err)
+	}
+
+	if layer.Width != 9 || layer.Height != 3 {
+		t.Fatalf("Dimensions: got %dx%d, want 9x3", layer.Width, layer.Height)
+	}
+
+	if got, want := layer.TextWithOptions(TextOptions{KeepTrailingSpaces: true}), "ab      c\n╔═╗      \n?        \n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	for i, cell := range layer.Cells {
+		if cell.Fg != fg || cell.Bg != bg {
+			t.Fatalf("Cell %d: got colors %v/%v, want %v/%v", i, cell.Fg, cell.Bg, fg, bg)
+		}
+	}
+}
+
+func TestLayerFromTextOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts TextOptions
+		want string
+	}{
+		{"tab width", "a\tb", TextOptions{TabWidth: 4}, "a   b\n"},
+		{"fixed width", "abcdef\nab", TextOptions{Width: 4}, "abcd\nab  \n"},
+		{"replacement", "a¤b", TextOptions{Replacement: '·'}, "a·b\n"},
+		{"keep", "a¤b", TextOptions{Unmappable: UnmappableKeep}, "a¤b\n"},
+		{"skip", "a¤b", TextOptions{Unmappable: UnmappableSkip}, "ab\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer, err := LayerFromTextWithOptions(strings.NewReader(tt.in), Color{}, Color{}, tt.opts)
+			if err != nil {
+				t.Fatalf("Failed to build layer: %v", err)
+			}
+			if got := layer.TextWithOptions(TextOptions{KeepTrailingSpaces: true}); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayerFromTextErrors(t *testing.T) {
+	_, err := LayerFromTextWithOptions(strings.NewReader("ok\nab¤"), Color{}, Color{}, TextOptions{Unmappable: UnmappableError})
+	if !errors.Is(err, ErrUnmappableRune) {
+		t.Errorf("Expected ErrUnmappableRune, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "line 2: column 3") {
+		t.Errorf("Expected the error to report the position, got %q", err)
+	}
+
+	if _, err := LayerFromText(strings.NewReader(""), Color{}, Color{}); err == nil {
+		t.Error("Expected an error for empty text")
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	in := "╔══╗\n║░▒║\n╚══╝\n"
+
+	layer, err := LayerFromText(strings.NewReader(in), Color{}, Color{})
+	if err != nil {
+		t.Fatalf("Failed to build layer: %v", err)
+	}
+	if got := layer.Text(); got != in {
+		t.Errorf("Got %q, want %q", got, in)
+	}
+}
@@ -0,0 +1,233 @@
+package xploader
+
+// Anchor selects the part of a layer that stays in place when its canvas is resized.
+type Anchor int
+
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorCenter
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
+)
+
+// directionalGlyphs lists sets of glyphs pointing up, right, down and left respectively.
+var directionalGlyphs = [][4]rune{
+	{'↑', '→', '↓', '←'},
+	{'▲', '►', '▼', '◄'},
+	{'▀', '▐', '▄', '▌'},
+}
+
+// axisGlyphs lists pairs of glyphs that are each other's counterpart rotated by 90 degrees, and are symmetric
+// otherwise.
+var axisGlyphs = [][2]rune{
+	{'↕', '↔'},
+}
+
+// Pairs of glyphs that are each other's mirror image.
+var (
+	horizontalMirrors = [][2]rune{{'(', ')'}, {'[', ']'}, {'{', '}'}, {'<', '>'}, {'/', '\\'}, {'«', '»'}, {'≤', '≥'}, {'⌐', '¬'}}
+	verticalMirrors   = [][2]rune{{'/', '\\'}, {'⌠', '⌡'}}
+)
+
+// Glyph remapping tables for the transforms, derived from the tables above and the box drawing glyphs.
+var (
+	flipHorizontalGlyphs         = map[rune]rune{}
+	flipVerticalGlyphs           = map[rune]rune{}
+	rotateClockwiseGlyphs        = map[rune]rune{}
+	rotateCounterClockwiseGlyphs = map[rune]rune{}
+)
+
+func init() {
+	for r, s := range boxGlyphs {
+		flipHorizontalGlyphs[r] = boxRunes[BoxSides{Up: s.Up, Right: s.Left, Down: s.Down, Left: s.Right}]
+		flipVerticalGlyphs[r] = boxRunes[BoxSides{Up: s.Down, Right: s.Right, Down: s.Up, Left: s.Left}]
+		rotateClockwiseGlyphs[r] = boxRunes[BoxSides{Up: s.Left, Right: s.Up, Down: s.Right, Left: s.Down}]
+	}
+
+	for _, d := range directionalGlyphs {
+		for i, r := range d {
+			rotateClockwiseGlyphs[r] = d[(i+1)%4]
+		}
+		flipHorizontalGlyphs[d[1]], flipHorizontalGlyphs[d[3]] = d[3], d[1]
+		flipVerticalGlyphs[d[0]], flipVerticalGlyphs[d[2]] = d[2], d[0]
+	}
+
+	for _, p := range axisGlyphs {
+		rotateClockwiseGlyphs[p[0]], rotateClockwiseGlyphs[p[1]] = p[1], p[0]
+	}
+	for _, p := range horizontalMirrors {
+		flipHorizontalGlyphs[p[0]], flipHorizontalGlyphs[p[1]] = p[1], p[0]
+	}
+	for _, p := range verticalMirrors {
+		flipVerticalGlyphs[p[0]], flipVerticalGlyphs[p[1]] = p[1], p[0]
+	}
+	// Slashes turn into each other when rotated by 90 degrees either way.
+	rotateClockwiseGlyphs['/'], rotateClockwiseGlyphs['\\'] = '\\', '/'
+
+	for r, rotated := range rotateClockwiseGlyphs {
+		rotateCounterClockwiseGlyphs[rotated] = r
+	}
+}
+
+// Crop returns a copy of the rectangle of the layer with its top left corner at (x, y) and the given width and
+// height. The rectangle is clipped to the layer's bounds.
+func (l *Layer) Crop(x, y, width, height int) *Layer {
+	x0, y0 := max(x, 0), max(y, 0)
+	x1, y1 := min(x+width, int(l.Width)), min(y+height, int(l.Height))
+	return l.reframe(max(x1-x0, 0), max(y1-y0, 0), -x0, -y0)
+}
+
+// Resize returns a copy of the layer with its canvas resized to the given dimensions. The anchor decides which part
+// of the layer stays in place: cells falling outside the new canvas are dropped and new cells are empty.
+func (l *Layer) Resize(width, height int, anchor Anchor) *Layer {
+	width, height = max(width, 0), max(height, 0)
+	col, row := int(anchor)%3, int(anchor)/3
+	return l.reframe(width, height, (width-int(l.Width))*col/2, (height-int(l.Height))*row/2)
+}
+
+// Pad returns a copy of the layer with the given number of empty cells added to each side. Negative values are
+// treated as zero.
+func (l *Layer) Pad(top, right, bottom, left int) *Layer {
+	top, right, bottom, left = max(top, 0), max(right, 0), max(bottom, 0), max(left, 0)
+	return l.reframe(int(l.Width)+left+right, int(l.Height)+top+bottom, left, top)
+}
+
+// reframe returns a layer of the given dimensions, with the same layout and metadata, holding the cells of l moved by
+// (dx, dy). Cells not covered by l are empty.
+func (l *Layer) reframe(width, height, dx, dy int) *Layer {
+	out := l.newLike(width, height)
+	for y := max(dy, 0); y < min(int(l.Height)+dy, height); y++ {
+		for x := max(dx, 0); x < min(int(l.Width)+dx, width); x++ {
+			out.Set(x, y, l.At(x-dx, y-dy))
+		}
+	}
+	return out
+}
+
+// FlipHorizontal returns a copy of the layer mirrored from left to right. When remap is true, directional glyphs such
+// as arrows and box drawing corners are replaced by their mirrored counterparts.
+func (l *Layer) FlipHorizontal(remap bool) *Layer {
+	w := int(l.Width)
+	return l.transform(w, int(l.Height), remap, flipHorizontalGlyphs, func(x, y int) (int, int) {
+		return w - 1 - x, y
+	})
+}
+
+// FlipVertical returns a copy of the layer mirrored from top to bottom. When remap is true, directional glyphs such
+// as arrows and box drawing corners are replaced by their mirrored counterparts.
+func (l *Layer) FlipVertical(remap bool) *Layer {
+	h := int(l.Height)
+	return l.transform(int(l.Width), h, remap, flipVerticalGlyphs, func(x, y int) (int, int) {
+		return x, h - 1 - y
+	})
+}
+
+// RotateClockwise returns a copy of the layer rotated by 90 degrees clockwise, swapping its width and height. When
+// remap is true, directional glyphs such as arrows and box drawing lines are replaced by their rotated counterparts.
+func (l *Layer) RotateClockwise(remap bool) *Layer {
+	h := int(l.Height)
+	return l.transform(h, int(l.Width), remap, rotateClockwiseGlyphs, func(x, y int) (int, int) {
+		return h - 1 - y, x
+	})
+}
+
+// RotateCounterClockwise returns a copy of the layer rotated by 90 degrees counterclockwise, swapping its width and
+// height. When remap is true, directional glyphs are replaced by their rotated counterparts.
+func (l *Layer) RotateCounterClockwise(remap bool) *Layer {
+	w := int(l.Width)
+	return l.transform(int(l.Height), w, remap, rotateCounterClockwiseGlyphs, func(x, y int) (int, int) {
+		return y, w - 1 - x
+	})
+}
+
+// Rotate180 returns a copy of the layer rotated by 180 degrees. When remap is true, directional glyphs are replaced by
+// their rotated counterparts.
+func (l *Layer) Rotate180(remap bool) *Layer {
+	return l.FlipHorizontal(remap).FlipVertical(remap)
+}
+
+// transform returns a layer of the given dimensions, with the same layout and metadata, holding every cell of l at the
+// position returned by move, its glyph remapped using glyphs when remap is true.
+func (l *Layer) transform(width, height int, remap bool, glyphs map[rune]rune, move func(x, y int) (int, int)) *Layer {
+	out := l.newLike(width, height)
+	for y := 0; y < int(l.Height); y++ {
+		for x := 0; x < int(l.Width); x++ {
+			cell := l.At(x, y)
+			if r, ok := glyphs[cell.Rune]; ok && remap {
+				cell.Rune = r
+			}
+			nx, ny := move(x, y)
+			out.Set(nx, ny, cell)
+		}
+	}
+	return out
+}
+
+// newLike returns an empty layer of the given dimensions with the same layout as l and a copy of its metadata.
+func (l *Layer) newLike(width, height int) *Layer {
+	out := NewEmptyLayer(width, height)
+	out.ColumnMajor = l.ColumnMajor
+	out.Meta = l.Meta.clone()
+	return out
+}
+
+// Crop returns a copy of the XPFile with every layer cropped, see Layer.Crop.
+func (xp *XPFile) Crop(x, y, width, height int) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.Crop(x, y, width, height) })
+}
+
+// Resize returns a copy of the XPFile with the canvas of every layer resized, see Layer.Resize.
+func (xp *XPFile) Resize(width, height int, anchor Anchor) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.Resize(width, height, anchor) })
+}
+
+// Pad returns a copy of the XPFile with every layer padded, see Layer.Pad.
+func (xp *XPFile) Pad(top, right, bottom, left int) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.Pad(top, right, bottom, left) })
+}
+
+// FlipHorizontal returns a copy of the XPFile with every layer mirrored from left to right, see Layer.FlipHorizontal.
+func (xp *XPFile) FlipHorizontal(remap bool) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.FlipHorizontal(remap) })
+}
+
+// FlipVertical returns a copy of the XPFile with every layer mirrored from top to bottom, see Layer.FlipVertical.
+func (xp *XPFile) FlipVertical(remap bool) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.FlipVertical(remap) })
+}
+
+// RotateClockwise returns a copy of the XPFile with every layer rotated by 90 degrees clockwise, see
+// Layer.RotateClockwise.
+func (xp *XPFile) RotateClockwise(remap bool) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.RotateClockwise(remap) })
+}
+
+// RotateCounterClockwise returns a copy of the XPFile with every layer rotated by 90 degrees counterclockwise, see
+// Layer.RotateCounterClockwise.
+func (xp *XPFile) RotateCounterClockwise(remap bool) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.RotateCounterClockwise(remap) })
+}
+
+// Rotate180 returns a copy of the XPFile with every layer rotated by 180 degrees, see Layer.Rotate180.
+func (xp *XPFile) Rotate180(remap bool) *XPFile {
+	return xp.transform(func(l *Layer) *Layer { return l.Rotate180(remap) })
+}
+
+// transform returns a copy of the XPFile with fn applied to every layer.
+func (xp *XPFile) transform(fn func(*Layer) *Layer) *XPFile {
+	out := &XPFile{
+		Version: xp.Version,
+		Layers:  make([]Layer, len(xp.Layers)),
+		Meta:    xp.Meta.clone(),
+		sidecar: xp.sidecar,
+	}
+	for i := range xp.Layers {
+		out.Layers[i] = *fn(&xp.Layers[i])
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package xploader
+
+import (
+	"strings"
+	"testing"
+)
+
+func textLayer(t *testing.T, s string) *Layer {
+	t.Helper()
+	layer, err := LayerFromText(strings.NewReader(s), Color{}, Color{})
+	if err != nil {
+		t.Fatalf("Failed to build layer: %v", err)
+	}
+	return layer
+}
+
+func TestLayerTransforms(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		transform func(*Layer) *Layer
+		want      string
+	}{
+		{"crop", "abc\ndef\nghi", func(l *Layer) *Layer { return l.Crop(1, 1, 5, 5) }, "ef\nhi\n"},
+		{"crop outside", "abc", func(l *Layer) *Layer { return l.Crop(5, 5, 2, 2) }, ""},
+		{"resize center", "ab\ncd", func(l *Layer) *Layer { return l.Resize(4, 4, AnchorCenter) }, "\n ab\n cd\n\n"},
+		{"resize top left", "ab\ncd", func(l *Layer) *Layer { return l.Resize(3, 1, AnchorTopLeft) }, "ab\n"},
+		{"shrink bottom right", "abc\ndef", func(l *Layer) *Layer { return l.Resize(2, 1, AnchorBottomRight) }, "ef\n"},
+		{"pad", "ab", func(l *Layer) *Layer { return l.Pad(1, 2, 1, 3) }, "\n   ab\n\n"},
+		{"flip horizontal", "┌─►\n│ ↑", func(l *Layer) *Layer { return l.FlipHorizontal(true) }, "◄─┐\n↑ │\n"},
+		{"flip horizontal no remap", "┌─►\n│ ↑", func(l *Layer) *Layer { return l.FlipHorizontal(false) }, "►─┌\n↑ │\n"},
+		{"flip vertical", "╔═╕\n▀/↑", func(l *Layer) *Layer { return l.FlipVertical(true) }, "▄\\↓\n╚═╛\n"},
+		{"rotate clockwise", "ab\ncd", func(l *Layer) *Layer { return l.RotateClockwise(false) }, "ca\ndb\n"},
+		{"rotate clockwise remap", "┌─\n│►", func(l *Layer) *Layer { return l.RotateClockwise(true) }, "─┐\n▼│\n"},
+		{"rotate counterclockwise", "abc", func(l *Layer) *Layer { return l.RotateCounterClockwise(false) }, "c\nb\na\n"},
+		{"rotate counterclockwise remap", "╒↔", func(l *Layer) *Layer { return l.RotateCounterClockwise(true) }, "↕\n╙\n"},
+		{"rotate 180", "ab\n┌►", func(l *Layer) *Layer { return l.Rotate180(true) }, "◄┘\nba\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.transform(textLayer(t, tt.in)).Text(); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayerResizeDimensions(t *testing.T) {
+	layer := NewEmptyLayer(3, 2)
+	layer.Fill(Cell{Rune: 'x'})
+	layer.ColumnMajor = true
+	layer.Meta = &LayerMeta{Name: "resized"}
+
+	out := layer.Pad(1, 1, 1, 1)
+	if out.Width != 5 || out.Height != 4 || len(out.Cells) != 20 {
+		t.Fatalf("Got %dx%d with %d cells, want 5x4 with 20 cells", out.Width, out.Height, len(out.Cells))
+	}
+	if !out.ColumnMajor {
+		t.Error("The layout should be preserved")
+	}
+	if out.Meta == layer.Meta || out.Meta.Name != "resized" {
+		t.Error("The metadata should be copied")
+	}
+	if c := out.At(0, 0); c != NewEmptyCell() {
+		t.Errorf("Padding: got %+v, want an empty cell", c)
+	}
+	if c := out.At(1, 1); c.Rune != 'x' {
+		t.Errorf("Content: got %+v, want the original cell", c)
+	}
+}
+
+func TestGlyphRemapsAreConsistent(t *testing.T) {
+	for r, m := range flipHorizontalGlyphs {
+		if back := flipHorizontalGlyphs[m]; back != r {
+			t.Errorf("Horizontal flip of %q twice gives %q", r, back)
+		}
+	}
+	for r, m := range flipVerticalGlyphs {
+		if back := flipVerticalGlyphs[m]; back != r {
+			t.Errorf("Vertical flip of %q twice gives %q", r, back)
+		}
+	}
+	for r, m := range rotateClockwiseGlyphs {
+		if back := rotateCounterClockwiseGlyphs[m]; back != r {
+			t.Errorf("Rotating %q clockwise and back gives %q", r, back)
+		}
+		full := r
+		for range 4 {
+			full = rotateClockwiseGlyphs[full]
+		}
+		if full != r {
+			t.Errorf("Rotating %q four times gives %q", r, full)
+		}
+	}
+}
+
+func TestXPFileTransforms(t *testing.T) {
+	xp := &XPFile{
+		Version: defaultVersion,
+		Layers:  []Layer{*textLayer(t, "abc\ndef"), *textLayer(t, "ghi\njkl")},
+		Meta:    &FileMeta{Title: "transformed"},
+	}
+
+	out := xp.Crop(1, 0, 2, 1)
+	if len(out.Layers) != 2 {
+		t.Fatalf("Got %d layers, want 2", len(out.Layers))
+	}
+	for i, want := range []string{"bc\n", "hi\n"} {
+		if got := out.Layers[i].Text(); got != want {
+			t.Errorf("Layer %d: got %q, want %q", i, got, want)
+		}
+	}
+	if out.Meta == xp.Meta || out.Meta.Title != "transformed" {
+		t.Error("The metadata should be copied")
+	}
+	if got := xp.Layers[0].Text(); got != "abc\ndef\n" {
+		t.Errorf("The original XPFile was modified: %q", got)
+	}
+
+	if got := xp.RotateClockwise(false).Layers[1].Text(); got != "jg\nkh\nli\n" {
+		t.Errorf("Rotate: got %q", got)
+	}
+}
@@ -0,0 +1,150 @@
+package xploader
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXMLRoundTrip(t *testing.T) {
+	for _, name := range []string{"simple.xp", "allchars.xp"} {
+		t.Run(name, func(t *testing.T) {
+			xp, err := LoadXPFile(filepath.Join(testDataDir, name))
+			if err != nil {
+				t.Fatalf("Failed to load %q: %v", name, err)
+			}
+
+			var buf bytes.Buffer
+			if err := EncodeXML(&buf, xp); err != nil {
+				t.Fatalf("Failed to encode XML: %v", err)
+			}
+
+			decoded, err := DecodeXML(&buf)
+			if err != nil {
+				t.Fatalf("Failed to decode XML: %v", err)
+			}
+
+			assertXPFileEqual(xp, decoded, t)
+		})
+	}
+}
+
+func TestXMLRoundTripUnmappableRunes(t *testing.T) {
+	// The currency sign lies below 256 but has no CP437 equivalent, the emoji lies far beyond it.
+	layer := NewEmptyLayer(3, 1)
+	layer.Set(0, 0, Cell{Rune: 'A', Fg: Color{R: 255}, Bg: Color{}})
+	layer.Set(1, 0, Cell{Rune: '¤', Fg: Color{R: 255}, Bg: Color{}})
+	layer.Set(2, 0, Cell{Rune: '😀', Fg: Color{R: 255}, Bg: Color{}})
+
+	var buf bytes.Buffer
+	if err := EncodeXML(&buf, newXpFile(*layer, t)); err != nil {
+		t.Fatalf("Failed to encode XML: %v", err)
+	}
+
+	decoded, err := DecodeXML(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode XML: %v", err)
+	}
+	if got, want := decoded.Layers[0].Text(), "A??\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestXMLFlattensLayers(t *testing.T) {
+	xp, err := LoadXPFile(filepath.Join(testDataDir, "multilayer.xp"))
+	if err != nil {
+		t.Fatalf("Failed to load multilayer XP file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeXML(&buf, xp); err != nil {
+		t.Fatalf("Failed to encode XML: %v", err)
+	}
+
+	decoded, err := DecodeXML(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode XML: %v", err)
+	}
+
+	assertXPFileEqual(&XPFile{Version: xp.Version, Layers: []Layer{*xp.Flatten()}}, decoded, t)
+}
+
+func TestEncodeXMLFormat(t *testing.T) {
+	layer := NewEmptyLayer(2, 1)
+	layer.Set(0, 0, Cell{Rune: '☺', Fg: Color{R: 255, G: 255, B: 255}, Bg: Color{}})
+
+	var buf bytes.Buffer
+	if err := EncodeXML(&buf, &XPFile{Version: -1, Layers: []Layer{*layer}}); err != nil {
+		t.Fatalf("Failed to encode XML: %v", err)
+	}
+
+	expected := `<image>
+	<width>2</width>
+	<height>1</height>
+	<data>
+		<row>
+			<cell>
+				<ascii>1</ascii>
+				<fgd>#ffffff</fgd>
+				<bkg>#000000</bkg>
+			</cell>
+			<cell>
+				<ascii>32</ascii>
+				<fgd>#000000</fgd>
+				<bkg>#ff00ff</bkg>
+			</cell>
+		</row>
+	</data>
+</image>`
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected XML output:\n%s", buf.String())
+	}
+}
+
+func TestDecodeXML(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<image><width>2</width><height>1</height><data><row>` +
+		`<cell><ascii>176</ascii><fgd>#FF0000</fgd><bkg>#00FF00</bkg></cell>` +
+		`<cell><ascii>0</ascii><fgd>#000000</fgd><bkg>#FF00FF</bkg></cell>` +
+		`</row></data></image>`
+
+	xp, err := DecodeXML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Failed to decode XML: %v", err)
+	}
+
+	layer := xp.Layers[0]
+	if got, want := layer.At(0, 0), (Cell{Rune: '░', Fg: Color{R: 255}, Bg: Color{G: 255}}); got != want {
+		t.Errorf("Cell (0,0): expected %+v, got %+v", want, got)
+	}
+	if got := layer.At(1, 0); !got.IsEmpty() {
+		t.Errorf("Cell (1,0): expected empty cell, got %+v", got)
+	}
+}
+
+func TestDecodeXMLErrors(t *testing.T) {
+	cell := `<cell><ascii>65</ascii><fgd>#000000</fgd><bkg>#000000</bkg></cell>`
+
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "Malformed", doc: `<image><width>`},
+		{name: "ZeroWidth", doc: `<image><width>0</width><height>1</height><data><row></row></data></image>`},
+		{name: "MissingRow", doc: `<image><width>1</width><height>2</height><data><row>` + cell + `</row></data></image>`},
+		{name: "HugeWidth", doc: `<image><width>4611686018427387903</width><height>1</height><data><row></row></data></image>`},
+		{name: "LongRow", doc: `<image><width>1</width><height>1</height><data><row>` + cell + cell + `</row></data></image>`},
+		{name: "ShortRow", doc: `<image><width>2</width><height>1</height><data><row>` + cell + `</row></data></image>`},
+		{name: "BadColor", doc: `<image><width>1</width><height>1</height><data><row><cell><ascii>65</ascii><fgd>red</fgd><bkg>#000000</bkg></cell></row></data></image>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeXML(strings.NewReader(tt.doc)); err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
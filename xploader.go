@@ -8,12 +8,14 @@
 package xploader
 
 import (
+	"bufio"
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
 )
 
@@ -22,7 +24,9 @@ const (
 	gzipID2 byte = 0x8B
 )
 
-// LoadOptions controls how XP files are loaded.
+// LoadOptions controls how XP files are loaded. Files are always loaded with resource limits: the zero value of each
+// limit applies DefaultMaxLayers, DefaultMaxCells and DefaultMaxDecompressedBytes respectively, so untrusted data can
+// be loaded with the default options. Set a limit to a negative value to disable it.
 type LoadOptions struct {
 	// ColumnMajor preserves REXPaint's native column-major layout when true. Defaults to false, which returns data in
 	// row-major order.
@@ -31,12 +35,68 @@ type LoadOptions struct {
 	// RuneDecoder overrides how CP437 code points (0–255) are decoded to Unicode runes.
 	// If nil, CP437Decoder is used. Useful when working with custom fonts.
 	RuneDecoder func(int32) rune
+
+	// MaxLayers limits the number of layers a file may declare. Zero means DefaultMaxLayers, a negative value means no
+	// limit.
+	MaxLayers int
+
+	// MaxWidth and MaxHeight limit the dimensions of each layer. Zero means no limit, as MaxCells bounds the size of
+	// a layer anyway.
+	MaxWidth, MaxHeight int
+
+	// MaxCells limits the total number of cells over all layers. Zero means DefaultMaxCells, a negative value means no
+	// limit.
+	MaxCells int
+
+	// MaxDecompressedBytes limits the size of the uncompressed XP stream, protecting against decompression bombs.
+	// Zero means DefaultMaxDecompressedBytes, a negative value means no limit.
+	MaxDecompressedBytes int64
+
+	// Sidecar loads layer and file metadata from the JSON file next to the .xp file, see SidecarPath, when it exists.
+	// Only used by LoadXPFileWithOptions. Defaults to true.
+	Sidecar bool
 }
 
+// Default resource limits applied by the loader when the corresponding LoadOptions field is zero. They accept any file
+// REXPaint produces in practice while keeping untrusted data from exhausting memory.
+const (
+	// DefaultMaxLayers is the maximum number of layers REXPaint supports.
+	DefaultMaxLayers = 9
+
+	// DefaultMaxCells allows for about 4 million cells over all layers, e.g. a single layer of 2048x2048 cells.
+	DefaultMaxCells = 1 << 22
+
+	// DefaultMaxDecompressedBytes comfortably fits DefaultMaxCells worth of cell data.
+	DefaultMaxDecompressedBytes = 64 << 20
+)
+
+// withDefaultLimits returns the options with the zero limits replaced by their defaults. Negative limits are left as
+// is: the loader only enforces positive ones.
+func (o LoadOptions) withDefaultLimits() LoadOptions {
+	if o.MaxLayers == 0 {
+		o.MaxLayers = DefaultMaxLayers
+	}
+	if o.MaxCells == 0 {
+		o.MaxCells = DefaultMaxCells
+	}
+	if o.MaxDecompressedBytes == 0 {
+		o.MaxDecompressedBytes = DefaultMaxDecompressedBytes
+	}
+	return o
+}
+
+// maxPreallocCells caps how many cells of a layer are allocated up front based on the, possibly bogus, dimensions in
+// the layer header. Larger layers grow as their cells are read.
+const maxPreallocCells = 1 << 16
+
+// maxPreallocLayers caps how many layers are allocated up front based on the, possibly bogus, layer count in the file
+// header. REXPaint supports at most 9 layers.
+const maxPreallocLayers = 9
+
 // LoadXPFile loads a REXPaint .xp file from a filesystem path with default options and returns a pointer to an XPFile
 // struct containing the fully parsed XP stream.
 func LoadXPFile(path string) (*XPFile, error) {
-	return LoadXPFileWithOptions(path, LoadOptions{ColumnMajor: false, RuneDecoder: CP437Decoder})
+	return LoadXPFileWithOptions(path, LoadOptions{ColumnMajor: false, RuneDecoder: CP437Decoder, Sidecar: true})
 }
 
 // LoadXPFileWithOptions loads a REXPaint .xp file with the specified options and returns a pointer to an XPFile struct
@@ -48,15 +108,34 @@ func LoadXPFileWithOptions(path string, opts LoadOptions) (*XPFile, error) {
 	}
 	defer f.Close()
 
-	return LoadXPFromReader(f, opts)
+	xp, err := LoadXPFromReader(f, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Sidecar {
+		sidecarPath := SidecarPath(path)
+		loaded, err := loadSidecar(sidecarPath, xp)
+		if err != nil {
+			return nil, err
+		}
+		if loaded {
+			xp.sidecar = sidecarPath
+		}
+	}
+
+	return xp, nil
 }
 
 // LoadXPFromReader loads a REXPaint .xp file from a reader with options and returns a pointer to an XPFile struct
 // containing the fully parsed XP stream.
+//
+// Errors caused by malformed data are returned as *ParseError and can be matched against ErrTruncated,
+// ErrCorruptHeader, ErrCorruptData, ErrInvalidDimensions and ErrLimitExceeded using errors.Is.
 func LoadXPFromReader(r io.Reader, opts LoadOptions) (*XPFile, error) {
 	isGzip, r, err := detectGzip(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect gzip: %w", err)
+		return nil, newParseError("gzip magic", -1, 0, err)
 	}
 
 	if isGzip {
@@ -69,33 +148,58 @@ func LoadXPFromReader(r io.Reader, opts LoadOptions) (*XPFile, error) {
 // struct containing the fully parsed XP stream. It will fail if the source data is not gzipped.
 func LoadGzippedXPFromReader(r io.Reader, opts LoadOptions) (*XPFile, error) {
 	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, newParseError("gzip header", -1, 0, err)
+	}
+
+	opts = opts.withDefaultLimits()
+	or := newOffsetReader(gr, opts)
+	xp, err := readXP(or, opts)
 	if err != nil {
 		return nil, err
 	}
-	return LoadPlainXPFromReader(gr, opts)
+
+	// Consume the remainder of the stream so the gzip reader verifies the checksum.
+	if _, err := io.Copy(io.Discard, or); err != nil {
+		return nil, newParseError("gzip trailer", -1, or.n, err)
+	}
+
+	return xp, nil
 }
 
 // LoadPlainXPFromReader loads a RexPaint .xp file from an io.Reader.
 func LoadPlainXPFromReader(r io.Reader, opts LoadOptions) (*XPFile, error) {
+	opts = opts.withDefaultLimits()
+	return readXP(newOffsetReader(r, opts), opts)
+}
+
+// readXP reads the uncompressed XP stream.
+func readXP(or *offsetReader, opts LoadOptions) (*XPFile, error) {
 	var version int32
-	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
-		return nil, fmt.Errorf("failed to read version: %w", err)
+	if err := binary.Read(or, binary.LittleEndian, &version); err != nil {
+		return nil, newParseError("version", -1, 0, err)
 	}
 
 	var layerCount uint32
-	if err := binary.Read(r, binary.LittleEndian, &layerCount); err != nil {
-		return nil, fmt.Errorf("failed to read layer count: %w", err)
+	offset := or.n
+	if err := binary.Read(or, binary.LittleEndian, &layerCount); err != nil {
+		return nil, newParseError("layer count", -1, offset, err)
+	}
+
+	if opts.MaxLayers > 0 && uint64(layerCount) > uint64(opts.MaxLayers) {
+		return nil, newLimitError("layer count", -1, offset, "%d layers exceeds MaxLayers of %d", layerCount, opts.MaxLayers)
 	}
 
 	xp := &XPFile{
 		Version: version,
-		Layers:  make([]Layer, 0, layerCount),
+		Layers:  make([]Layer, 0, min(layerCount, maxPreallocLayers)),
 	}
 
+	lr := newLayerReader(or, opts)
 	for i := uint32(0); i < layerCount; i++ {
-		layer, err := readLayer(r, opts)
+		layer, err := lr.readLayer(int(i))
 		if err != nil {
-			return nil, fmt.Errorf("failed to read layer %d: %w", i, err)
+			return nil, err
 		}
 		xp.Layers = append(xp.Layers, *layer)
 	}
@@ -119,78 +223,185 @@ func detectGzip(r io.Reader) (bool, io.Reader, error) {
 	return false, reader, nil
 }
 
-// readLayer reads a single layer from the XP file.
-func readLayer(r io.Reader, opts LoadOptions) (*Layer, error) {
-	var width, height uint32
+// cellSize is the size in bytes of a single cell in the XP stream: a little endian int32 code point followed by the
+// RGB foreground and background colors.
+const cellSize = 10
 
-	if err := binary.Read(r, binary.LittleEndian, &width); err != nil {
-		return nil, fmt.Errorf("failed to read layer width: %w", err)
-	}
-	if err := binary.Read(r, binary.LittleEndian, &height); err != nil {
-		return nil, fmt.Errorf("failed to read layer height: %w", err)
+// maxChunkCells caps the number of cells decoded per read, so huge layers do not require a second copy of their cell
+// data in memory.
+const maxChunkCells = 4096
+
+// layerReader decodes the layers of an uncompressed XP stream.
+type layerReader struct {
+	r    *offsetReader
+	opts LoadOptions
+
+	// runes caches the decoded runes for code points 0-255 so RuneDecoder is not called for every single cell.
+	runes [256]rune
+
+	// buf is the read buffer, reused between layers.
+	buf []byte
+
+	// cells holds the number of cells read so far and is used to enforce LoadOptions.MaxCells.
+	cells int
+}
+
+// newLayerReader returns a layerReader reading from r.
+func newLayerReader(r *offsetReader, opts LoadOptions) *layerReader {
+	lr := &layerReader{r: r, opts: opts}
+	for code := range lr.runes {
+		lr.runes[code] = lr.decodeRune(int32(code))
 	}
+	return lr
+}
 
-	// Memory allocation based on desired layout.
-	var outer, inner uint32
-	outer = height
-	inner = width
-	if opts.ColumnMajor {
-		outer = width
-		inner = height
+// decodeRune decodes a code point using the RuneDecoder of the LoadOptions. Code point 0 is rendered as a space.
+func (lr *layerReader) decodeRune(code int32) rune {
+	ru := code
+	if lr.opts.RuneDecoder != nil {
+		ru = lr.opts.RuneDecoder(code)
 	}
 
-	cells := make([][]Cell, outer)
-	for i := uint32(0); i < outer; i++ {
-		cells[i] = make([]Cell, inner)
+	if ru == '\x00' {
+		ru = ' '
 	}
+	return ru
+}
 
-	for x := uint32(0); x < width; x++ {
-		for y := uint32(0); y < height; y++ {
-			var codepoint int32
-			var fg Color
-			var bg Color
+// readLayer reads the layer with the given index from the XP file.
+func (lr *layerReader) readLayer(index int) (*Layer, error) {
+	var width, height uint32
 
-			if err := binary.Read(r, binary.LittleEndian, &codepoint); err != nil {
-				return nil, fmt.Errorf("failed to read codepoint at (%d,%d): %w", x, y, err)
-			}
+	offset := lr.r.n
+	if err := binary.Read(lr.r, binary.LittleEndian, &width); err != nil {
+		return nil, newParseError("layer width", index, offset, err)
+	}
+	offset = lr.r.n
+	if err := binary.Read(lr.r, binary.LittleEndian, &height); err != nil {
+		return nil, newParseError("layer height", index, offset, err)
+	}
 
-			if err := binary.Read(r, binary.LittleEndian, &fg); err != nil {
-				return nil, fmt.Errorf("failed to read foreground color at (%d,%d): %w", x, y, err)
-			}
+	if err := checkDimensions(width, height); err != nil {
+		e := newParseError("layer dimensions", index, offset-4, err)
+		e.Kind = KindInvalidDimensions
+		return nil, e
+	}
 
-			if err := binary.Read(r, binary.LittleEndian, &bg); err != nil {
-				return nil, fmt.Errorf("failed to read background color at (%d,%d): %w", x, y, err)
-			}
+	if err := checkLimits(width, height, lr.cells, lr.opts); err != nil {
+		e := newParseError("layer dimensions", index, offset-4, err)
+		e.Kind = KindLimitExceeded
+		return nil, e
+	}
 
-			ru := codepoint
-			if opts.RuneDecoder != nil {
-				ru = opts.RuneDecoder(codepoint)
-			}
+	// Cells are stored in column-major order: cell i lives at (i / height, i % height). The header may declare far
+	// more cells than the stream holds, so they are allocated as they are read and only reordered once complete.
+	total := int(width) * int(height)
+	h := int(height)
+	cells := make([]Cell, 0, min(total, maxPreallocCells))
+	for start := 0; start < total; start += maxChunkCells {
+		n := min(total-start, maxChunkCells)
+
+		offset = lr.r.n
+		chunk := lr.chunk(n * cellSize)
+		if read, err := io.ReadFull(lr.r, chunk); err != nil {
+			i := start + read/cellSize
+			field, fieldOffset := cellField(read % cellSize)
+			return nil, newCellParseError(field, index, i/h, i%h, offset+int64(read-read%cellSize+fieldOffset), err)
+		}
 
-			if ru == '\x00' {
-				ru = ' '
+		for c := 0; c < n; c++ {
+			b := chunk[c*cellSize : (c+1)*cellSize]
+
+			code := int32(binary.LittleEndian.Uint32(b))
+			var ru rune
+			if code >= 0 && code < int32(len(lr.runes)) {
+				ru = lr.runes[code]
+			} else {
+				ru = lr.decodeRune(code)
 			}
 
-			cell := Cell{
+			cells = append(cells, Cell{
 				Rune: ru,
-				Fg:   fg,
-				Bg:   bg,
-			}
+				Fg:   Color{R: b[4], G: b[5], B: b[6]},
+				Bg:   Color{R: b[7], G: b[8], B: b[9]},
+			})
+		}
+	}
 
-			if opts.ColumnMajor {
-				cells[x][y] = cell
-			} else {
-				cells[y][x] = cell
-			}
+	if !lr.opts.ColumnMajor {
+		rows := make([]Cell, total)
+		w := int(width)
+		for i, cell := range cells {
+			rows[(i%h)*w+i/h] = cell
 		}
+		cells = rows
 	}
 
-	return &Layer{
-		ColumnMajor: opts.ColumnMajor,
+	layer := &Layer{
+		ColumnMajor: lr.opts.ColumnMajor,
 		Width:       width,
 		Height:      height,
 		Cells:       cells,
-	}, nil
+	}
+
+	lr.cells += total
+
+	return layer, nil
+}
+
+// chunk returns the read buffer resized to n bytes, growing it when needed.
+func (lr *layerReader) chunk(n int) []byte {
+	if cap(lr.buf) < n {
+		lr.buf = make([]byte, n)
+	}
+	return lr.buf[:n]
+}
+
+// cellField returns the name and offset within a cell of the field containing byte i of that cell.
+func cellField(i int) (string, int) {
+	switch {
+	case i < 4:
+		return "codepoint", 0
+	case i < 7:
+		return "foreground color", 4
+	}
+	return "background color", 7
+}
+
+// checkDimensions rejects layer dimensions REXPaint can never produce: empty layers and layers whose cell count does
+// not fit in an int.
+func checkDimensions(width, height uint32) error {
+	if width == 0 || height == 0 {
+		return fmt.Errorf("layer of %dx%d cells is empty", width, height)
+	}
+	if width > math.MaxInt32 || height > math.MaxInt32 || uint64(width)*uint64(height) > math.MaxInt {
+		return fmt.Errorf("layer of %dx%d cells is too large", width, height)
+	}
+	return nil
+}
+
+// checkLimits enforces the resource limits of the LoadOptions on a layer of the given dimensions. It must be called
+// after checkDimensions so the cell count cannot overflow.
+func checkLimits(width, height uint32, prevCells int, opts LoadOptions) error {
+	if opts.MaxWidth > 0 && uint64(width) > uint64(opts.MaxWidth) {
+		return fmt.Errorf("width of %d exceeds MaxWidth of %d", width, opts.MaxWidth)
+	}
+	if opts.MaxHeight > 0 && uint64(height) > uint64(opts.MaxHeight) {
+		return fmt.Errorf("height of %d exceeds MaxHeight of %d", height, opts.MaxHeight)
+	}
+	if total := uint64(prevCells) + uint64(width)*uint64(height); opts.MaxCells > 0 && total > uint64(opts.MaxCells) {
+		return fmt.Errorf("%d cells exceeds MaxCells of %d", total, opts.MaxCells)
+	}
+	return nil
+}
+
+// checkCellCount rejects layers of more than DefaultMaxCells cells. It protects decoders of formats in which the layer
+// dimensions are declared or derived independently of the amount of cell data, before they allocate the layer.
+func checkCellCount(width, height int) error {
+	if width > DefaultMaxCells || height > DefaultMaxCells || width*height > DefaultMaxCells {
+		return fmt.Errorf("layer of %dx%d cells exceeds the maximum of %d cells", width, height, DefaultMaxCells)
+	}
+	return nil
 }
 
 // SaveOptions controls how XP files are saved.
@@ -204,34 +415,102 @@ type SaveOptions struct {
 	// RuneEncoder overrides how Unicode runes are mapped to CP437 code points. If nil, runes are written as-is.
 	// Useful for supporting custom fonts.
 	RuneEncoder func(rune) int32
+
+	// Atomic writes the file to a temporary file in the target directory first, syncs it to disk and then renames it
+	// over the target path. A crash or error halfway through will never leave a truncated file behind. The permissions
+	// of an existing target file are preserved. Defaults to true.
+	Atomic bool
+
+	// Backup keeps the previous version of the target file, if any, next to it with BackupSuffix appended to its name.
+	Backup bool
+
+	// Sidecar writes the layer and file metadata to a JSON file next to the target file, see SidecarPath. When the
+	// XPFile has no metadata, nothing is written. An existing sidecar is then only removed when the XPFile itself was
+	// loaded from it, with LoadOptions.Sidecar set, or saved it, so its metadata is known to be stale. Defaults to true.
+	Sidecar bool
 }
 
-// SaveXPFile saves the XPFile to the given path, always compressed (recommended standard).
+// SaveXPFile saves the XPFile to the given path, always compressed (recommended standard) and atomically.
 func SaveXPFile(xp *XPFile, path string) error {
-	return SaveXPFileWithOptions(xp, path, SaveOptions{Gzip: true, GzipLevel: flate.BestCompression, RuneEncoder: CP437Encoder})
+	return SaveXPFileWithOptions(xp, path, SaveOptions{
+		Gzip:        true,
+		GzipLevel:   flate.BestCompression,
+		RuneEncoder: CP437Encoder,
+		Atomic:      true,
+		Sidecar:     true,
+	})
 }
 
-// SaveXPFileWithOptions saves the XPFile with full control over compression.
+// SaveXPFileWithOptions saves the XPFile with full control over compression and crash safety. When opts.Sidecar is
+// set and the XPFile carries metadata, the metadata is written to a JSON sidecar file next to it, otherwise the sidecar
+// file is removed when the XPFile was loaded from or saved it, see SaveOptions.Sidecar.
 func SaveXPFileWithOptions(xp *XPFile, path string, opts SaveOptions) error {
-	data, err := Marshal(xp, opts)
+	err := writeFile(path, opts, func(w io.Writer) error {
+		return SaveXPToWriter(w, xp, opts)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal XP file: %w", err)
+		return err
 	}
 
-	if opts.Gzip {
-		data, err = GzipData(data, opts.GzipLevel)
+	if !opts.Sidecar {
+		return nil
+	}
+
+	sidecarPath := SidecarPath(path)
+	if xp.HasMeta() {
+		err := writeFile(sidecarPath, opts, func(w io.Writer) error {
+			return WriteMeta(w, xp)
+		})
 		if err != nil {
-			return fmt.Errorf("failed to compress XP data: %w", err)
+			return fmt.Errorf("failed to save sidecar: %w", err)
 		}
+		xp.sidecar = sidecarPath
+	} else if xp.sidecar == sidecarPath {
+		// The metadata was removed since the sidecar was loaded or saved, it would resurface when loading the file.
+		if err := removeFile(sidecarPath, opts); err != nil {
+			return fmt.Errorf("failed to remove stale sidecar: %w", err)
+		}
+		xp.sidecar = ""
+	}
+
+	return nil
+}
+
+// SaveXPToWriter streams the XPFile to the given writer, compressing it on the fly when opts.Gzip is set. It is the
+// counterpart of LoadXPFromReader.
+func SaveXPToWriter(w io.Writer, xp *XPFile, opts SaveOptions) error {
+	if opts.Gzip {
+		return SaveGzippedXPToWriter(w, xp, opts)
 	}
+	return SavePlainXPToWriter(w, xp, opts)
+}
 
-	f, err := os.Create(path)
+// SaveGzippedXPToWriter wraps the given io.Writer with a gzip.Writer using opts.GzipLevel and streams the XPFile
+// through it. The gzip stream is finalised before returning, the given writer is left open.
+func SaveGzippedXPToWriter(w io.Writer, xp *XPFile, opts SaveOptions) error {
+	gw, err := gzip.NewWriterLevel(w, opts.GzipLevel)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return fmt.Errorf("failed to create gzip writer: %w", err)
+	}
+
+	if err := writeXP(gw, xp, opts); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("failed to close gzip writer: %w", err)
 	}
-	defer f.Close()
 
-	if _, err := f.Write(data); err != nil {
+	return nil
+}
+
+// SavePlainXPToWriter streams the XPFile to the given io.Writer without compression.
+func SavePlainXPToWriter(w io.Writer, xp *XPFile, opts SaveOptions) error {
+	bw := bufio.NewWriter(w)
+
+	if err := writeXP(bw, xp, opts); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
 		return fmt.Errorf("failed to write XP data: %w", err)
 	}
 
@@ -242,21 +521,30 @@ func SaveXPFileWithOptions(xp *XPFile, path string, opts SaveOptions) error {
 func Marshal(xp *XPFile, opts SaveOptions) ([]byte, error) {
 	var buf bytes.Buffer
 
-	if err := binary.Write(&buf, binary.LittleEndian, xp.Version); err != nil {
-		return nil, fmt.Errorf("failed to write version: %w", err)
+	if err := writeXP(&buf, xp, opts); err != nil {
+		return nil, err
 	}
 
-	if err := binary.Write(&buf, binary.LittleEndian, uint32(len(xp.Layers))); err != nil {
-		return nil, fmt.Errorf("failed to write layer count: %w", err)
+	return buf.Bytes(), nil
+}
+
+// writeXP writes the uncompressed binary representation of the XPFile to w, always column-major.
+func writeXP(w io.Writer, xp *XPFile, opts SaveOptions) error {
+	if err := binary.Write(w, binary.LittleEndian, xp.Version); err != nil {
+		return fmt.Errorf("failed to write version: %w", err)
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(xp.Layers))); err != nil {
+		return fmt.Errorf("failed to write layer count: %w", err)
 	}
 
-	for i, layer := range xp.Layers {
-		if err := marshalLayer(&buf, &layer, opts); err != nil {
-			return nil, fmt.Errorf("failed to write layer %d: %w", i, err)
+	for i := range xp.Layers {
+		if err := marshalLayer(w, &xp.Layers[i], opts); err != nil {
+			return fmt.Errorf("failed to write layer %d: %w", i, err)
 		}
 	}
 
-	return buf.Bytes(), nil
+	return nil
 }
 
 // GzipData compresses the given raw binary data using the gzip format.
@@ -281,6 +569,13 @@ func GzipData(data []byte, level int) ([]byte, error) {
 
 // marshalLayer writes a single layer in column-major order.
 func marshalLayer(w io.Writer, layer *Layer, opts SaveOptions) error {
+	if err := checkDimensions(layer.Width, layer.Height); err != nil {
+		return err
+	}
+	if err := layer.checkCells(); err != nil {
+		return err
+	}
+
 	if err := binary.Write(w, binary.LittleEndian, layer.Width); err != nil {
 		return fmt.Errorf("failed to write layer width: %w", err)
 	}
@@ -290,24 +585,38 @@ func marshalLayer(w io.Writer, layer *Layer, opts SaveOptions) error {
 
 	width := int(layer.Width)
 	height := int(layer.Height)
+	total := width * height
+
+	buf := make([]byte, min(total, maxChunkCells)*cellSize)
+	n := 0
+	for i := 0; i < total; i++ {
+		var cell Cell
+		if layer.ColumnMajor {
+			cell = layer.Cells[i]
+		} else {
+			cell = layer.At(i/height, i%height)
+		}
+		r := cell.Rune
+		if opts.RuneEncoder != nil {
+			r = opts.RuneEncoder(r)
+		}
 
-	for x := 0; x < width; x++ {
-		for y := 0; y < height; y++ {
-			cell := layer.GetCell(x, y)
-			r := cell.Rune
-			if opts.RuneEncoder != nil {
-				r = opts.RuneEncoder(r)
-			}
+		b := buf[n*cellSize : (n+1)*cellSize]
+		binary.LittleEndian.PutUint32(b, uint32(r))
+		b[4], b[5], b[6] = cell.Fg.R, cell.Fg.G, cell.Fg.B
+		b[7], b[8], b[9] = cell.Bg.R, cell.Bg.G, cell.Bg.B
 
-			if err := binary.Write(w, binary.LittleEndian, r); err != nil {
-				return fmt.Errorf("failed to write rune: %w", err)
-			}
-			if err := binary.Write(w, binary.LittleEndian, cell.Fg); err != nil {
-				return fmt.Errorf("failed to write foreground color: %w", err)
-			}
-			if err := binary.Write(w, binary.LittleEndian, cell.Bg); err != nil {
-				return fmt.Errorf("failed to write background color: %w", err)
+		if n++; n*cellSize == len(buf) {
+			if _, err := w.Write(buf); err != nil {
+				return fmt.Errorf("failed to write cells: %w", err)
 			}
+			n = 0
+		}
+	}
+
+	if n > 0 {
+		if _, err := w.Write(buf[:n*cellSize]); err != nil {
+			return fmt.Errorf("failed to write cells: %w", err)
 		}
 	}
 
@@ -2,8 +2,14 @@ package xploader
 
 import (
 	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 )
 
@@ -24,46 +30,46 @@ func newSimpleLayer(t *testing.T) *Layer {
 	t.Helper()
 
 	simple := NewEmptyLayer(10, 15)
-	simple.Cells[0][0] = Cell{
+	simple.Set(0, 0, Cell{
 		Rune: 'x',
 		Fg:   Color{R: 255},
 		Bg:   Color{G: 128, B: 255},
-	}
-	simple.Cells[0][1] = Cell{
+	})
+	simple.Set(1, 0, Cell{
 		Rune: 'p',
 		Fg:   Color{R: 255, G: 255},
 		Bg:   Color{R: 191, B: 255},
-	}
-	simple.Cells[0][2] = Cell{
+	})
+	simple.Set(2, 0, Cell{
 		Rune: 'l',
 		Fg:   Color{R: 128, G: 255},
 		Bg:   Color{R: 255, B: 191},
-	}
-	simple.Cells[0][3] = Cell{
+	})
+	simple.Set(3, 0, Cell{
 		Rune: 'o',
 		Fg:   Color{G: 255},
 		Bg:   Color{R: 255, B: 128},
-	}
-	simple.Cells[0][4] = Cell{
+	})
+	simple.Set(4, 0, Cell{
 		Rune: 'a',
 		Fg:   Color{G: 255, B: 128},
 		Bg:   Color{R: 255, B: 64},
-	}
-	simple.Cells[0][5] = Cell{
+	})
+	simple.Set(5, 0, Cell{
 		Rune: 'd',
 		Fg:   Color{G: 255, B: 191},
 		Bg:   Color{R: 158, G: 158, B: 158},
-	}
-	simple.Cells[0][6] = Cell{
+	})
+	simple.Set(6, 0, Cell{
 		Rune: 'e',
 		Fg:   Color{G: 255, B: 255},
 		Bg:   Color{R: 158, G: 134, B: 100},
-	}
-	simple.Cells[0][7] = Cell{
+	})
+	simple.Set(7, 0, Cell{
 		Rune: 'r',
 		Fg:   Color{G: 191, B: 255},
 		Bg:   Color{R: 255, G: 255, B: 255},
-	}
+	})
 
 	return simple
 }
@@ -153,51 +159,51 @@ func TestLoadMultiLayerFile(t *testing.T) {
 	expected := newXpFile(*newSimpleLayer(t), t)
 
 	layerTwo := NewEmptyLayer(10, 15)
-	layerTwo.Cells[14][0] = Cell{
+	layerTwo.Set(0, 14, Cell{
 		Rune: 'E',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][1] = Cell{
+	})
+	layerTwo.Set(1, 14, Cell{
 		Rune: 'X',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][2] = Cell{
+	})
+	layerTwo.Set(2, 14, Cell{
 		Rune: 'P',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][3] = Cell{
+	})
+	layerTwo.Set(3, 14, Cell{
 		Rune: 'L',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][4] = Cell{
+	})
+	layerTwo.Set(4, 14, Cell{
 		Rune: 'O',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][5] = Cell{
+	})
+	layerTwo.Set(5, 14, Cell{
 		Rune: 'A',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][6] = Cell{
+	})
+	layerTwo.Set(6, 14, Cell{
 		Rune: 'D',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][7] = Cell{
+	})
+	layerTwo.Set(7, 14, Cell{
 		Rune: 'E',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
-	layerTwo.Cells[14][8] = Cell{
+	})
+	layerTwo.Set(8, 14, Cell{
 		Rune: 'R',
 		Fg:   Color{R: 255, G: 255, B: 255},
 		Bg:   Color{},
-	}
+	})
 
 	expected.AddLayer(*layerTwo)
 
@@ -245,6 +251,50 @@ func TestSaveXPFile(t *testing.T) {
 	assertXPFileEqual(xpOriginal, xpReloaded, t)
 }
 
+func TestSaveXPToWriter(t *testing.T) {
+	path := filepath.Join(testDataDir, "simple_plain.xp")
+
+	originalData, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read original plain XP file: %v", err)
+	}
+
+	xp, err := LoadXPFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load plain XP file: %v", err)
+	}
+
+	t.Run("Plain", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := SaveXPToWriter(&buf, xp, SaveOptions{RuneEncoder: CP437Encoder}); err != nil {
+			t.Fatalf("Failed to save plain XP data: %v", err)
+		}
+
+		if !bytes.Equal(originalData, buf.Bytes()) {
+			t.Fatalf("Plain output does not match original data (lengths: original=%d bytes, written=%d bytes)", len(originalData), buf.Len())
+		}
+	})
+
+	t.Run("Gzipped", func(t *testing.T) {
+		var buf bytes.Buffer
+		opts := SaveOptions{Gzip: true, GzipLevel: flate.BestCompression, RuneEncoder: CP437Encoder}
+		if err := SaveXPToWriter(&buf, xp, opts); err != nil {
+			t.Fatalf("Failed to save gzipped XP data: %v", err)
+		}
+
+		if b := buf.Bytes(); len(b) < 2 || b[0] != gzipID1 || b[1] != gzipID2 {
+			t.Fatal("Expected gzip header in output")
+		}
+
+		xpReloaded, err := LoadXPFromReader(&buf, LoadOptions{RuneDecoder: CP437Decoder})
+		if err != nil {
+			t.Fatalf("Failed to reload gzipped XP data: %v", err)
+		}
+
+		assertXPFileEqual(xp, xpReloaded, t)
+	})
+}
+
 func TestMarshal(t *testing.T) {
 	path := filepath.Join(testDataDir, "simple_plain.xp")
 
@@ -360,3 +410,372 @@ func TestAllCharsXPMapping(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadLimits(t *testing.T) {
+	path := filepath.Join(testDataDir, "multilayer.xp")
+	streamSize := int64(8 + 2*(8+10*15*10))
+
+	tests := []struct {
+		name string
+		opts LoadOptions
+		ok   bool
+	}{
+		{name: "DefaultLimits", opts: LoadOptions{}, ok: true},
+		{name: "NoLimits", opts: LoadOptions{MaxLayers: -1, MaxCells: -1, MaxDecompressedBytes: -1}, ok: true},
+		{name: "MaxLayers", opts: LoadOptions{MaxLayers: 2}, ok: true},
+		{name: "MaxLayersExceeded", opts: LoadOptions{MaxLayers: 1}},
+		{name: "MaxWidth", opts: LoadOptions{MaxWidth: 10}, ok: true},
+		{name: "MaxWidthExceeded", opts: LoadOptions{MaxWidth: 9}},
+		{name: "MaxHeight", opts: LoadOptions{MaxHeight: 15}, ok: true},
+		{name: "MaxHeightExceeded", opts: LoadOptions{MaxHeight: 14}},
+		{name: "MaxCells", opts: LoadOptions{MaxCells: 300}, ok: true},
+		{name: "MaxCellsExceeded", opts: LoadOptions{MaxCells: 299}},
+		{name: "MaxDecompressedBytes", opts: LoadOptions{MaxDecompressedBytes: streamSize}, ok: true},
+		{name: "MaxDecompressedBytesExceeded", opts: LoadOptions{MaxDecompressedBytes: streamSize - 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadXPFileWithOptions(path, tt.opts)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("Expected file to load within limits, got: %v", err)
+				}
+				return
+			}
+
+			assertParseError(err, KindLimitExceeded, t)
+			if !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("Expected errors.Is(err, ErrLimitExceeded), got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadLimitsBeforeAllocation(t *testing.T) {
+	// A header declaring billions of layers and a huge first layer, without any cell data following.
+	var buf bytes.Buffer
+	for _, v := range []any{int32(-1), uint32(0xFFFFFFFF), uint32(0x7FFFFFFF), uint32(0x7FFFFFFF)} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	data := buf.Bytes()
+
+	_, err := LoadXPFromReader(bytes.NewReader(data), LoadOptions{MaxLayers: 9})
+	assertParseError(err, KindLimitExceeded, t)
+
+	_, err = LoadXPFromReader(bytes.NewReader(data), LoadOptions{MaxCells: 1 << 20})
+	assertParseError(err, KindLimitExceeded, t)
+}
+
+// hugeLayerHeader returns a plain XP stream declaring a single layer of 0x7FFFFFFF by 0x7FFFFFFF cells, without any
+// cell data following.
+func hugeLayerHeader() []byte {
+	var buf bytes.Buffer
+	for _, v := range []any{int32(-1), uint32(1), uint32(0x7FFFFFFF), uint32(0x7FFFFFFF)} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadHugeLayerHeader(t *testing.T) {
+	data := hugeLayerHeader()
+
+	// The default limits reject the layer before anything is read.
+	_, err := LoadXPFromReader(bytes.NewReader(data), LoadOptions{})
+	assertParseError(err, KindLimitExceeded, t)
+
+	path := filepath.Join(t.TempDir(), "huge.xp")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadXPFile(path)
+	assertParseError(err, KindLimitExceeded, t)
+
+	// Without limits, cells are allocated as they are read, so the declared dimensions are never allocated up front.
+	_, err = LoadXPFromReader(bytes.NewReader(data), LoadOptions{MaxLayers: -1, MaxCells: -1, MaxDecompressedBytes: -1})
+	assertParseError(err, KindTruncated, t)
+}
+
+func TestLoadDefaultLimits(t *testing.T) {
+	var buf bytes.Buffer
+	for _, v := range []any{int32(-1), uint32(DefaultMaxLayers + 1)} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+
+	_, err := LoadXPFromReader(bytes.NewReader(buf.Bytes()), LoadOptions{})
+	assertParseError(err, KindLimitExceeded, t)
+
+	_, err = LoadXPFromReader(bytes.NewReader(buf.Bytes()), LoadOptions{MaxLayers: -1})
+	assertParseError(err, KindTruncated, t)
+}
+
+// fuzzLoadOptions keeps the fuzzers from running out of memory on headers declaring huge layers.
+var fuzzLoadOptions = LoadOptions{
+	RuneDecoder:          CP437Decoder,
+	MaxLayers:            16,
+	MaxCells:             1 << 16,
+	MaxDecompressedBytes: 1 << 20,
+}
+
+func addFuzzSeeds(f *testing.F) {
+	f.Helper()
+
+	files, err := filepath.Glob(filepath.Join(testDataDir, "*.xp"))
+	if err != nil {
+		f.Fatalf("Failed to list test data: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("Failed to read %q: %v", file, err)
+		}
+		f.Add(data)
+
+		// Add the plain variant of gzipped files and vice versa.
+		xp, err := LoadXPFromReader(bytes.NewReader(data), fuzzLoadOptions)
+		if err != nil {
+			f.Fatalf("Failed to load %q: %v", file, err)
+		}
+		plain, err := Marshal(xp, SaveOptions{RuneEncoder: CP437Encoder})
+		if err != nil {
+			f.Fatalf("Failed to marshal %q: %v", file, err)
+		}
+		f.Add(plain)
+		gzipped, err := GzipData(plain, flate.BestSpeed)
+		if err != nil {
+			f.Fatalf("Failed to gzip %q: %v", file, err)
+		}
+		f.Add(gzipped)
+	}
+}
+
+func FuzzLoadXPFromReader(f *testing.F) {
+	addFuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		xp, err := LoadXPFromReader(bytes.NewReader(data), fuzzLoadOptions)
+		if err != nil {
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Expected *ParseError, got %T: %v", err, err)
+			}
+			return
+		}
+
+		for i, layer := range xp.Layers {
+			if int(layer.Width)*int(layer.Height) == 0 {
+				t.Fatalf("Layer %d: loaded with empty dimensions %dx%d", i, layer.Width, layer.Height)
+			}
+			layer.GetCell(int(layer.Width)-1, int(layer.Height)-1)
+		}
+	})
+}
+
+func FuzzLoadXPFromReaderDefaultOptions(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Add(hugeLayerHeader())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if _, err := LoadXPFromReader(bytes.NewReader(data), LoadOptions{}); err != nil {
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Expected *ParseError, got %T: %v", err, err)
+			}
+		}
+	})
+}
+
+func FuzzMarshal(f *testing.F) {
+	addFuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		xp, err := LoadXPFromReader(bytes.NewReader(data), fuzzLoadOptions)
+		if err != nil {
+			return
+		}
+
+		saveOpts := SaveOptions{RuneEncoder: CP437Encoder}
+
+		// The first pass normalises runes (e.g. code 0 becomes a space), after that the round trip must be stable.
+		first, err := Marshal(xp, saveOpts)
+		if err != nil {
+			t.Fatalf("Failed to marshal loaded XP file: %v", err)
+		}
+		reloaded, err := LoadXPFromReader(bytes.NewReader(first), fuzzLoadOptions)
+		if err != nil {
+			t.Fatalf("Failed to reload marshaled XP file: %v", err)
+		}
+		second, err := Marshal(reloaded, saveOpts)
+		if err != nil {
+			t.Fatalf("Failed to marshal reloaded XP file: %v", err)
+		}
+
+		if !bytes.Equal(first, second) {
+			t.Fatal("Marshal round trip is not stable")
+		}
+	})
+}
+
+func TestMarshalInconsistentLayer(t *testing.T) {
+	tests := []struct {
+		name  string
+		layer *Layer
+	}{
+		{name: "MissingCells", layer: &Layer{Width: 2, Height: 2, Cells: []Cell{{}, {}, {}}}},
+		{name: "TooManyCells", layer: &Layer{ColumnMajor: true, Width: 3, Height: 1, Cells: []Cell{{}, {}, {}, {}}}},
+		{name: "NoCells", layer: &Layer{Width: 3, Height: 1}},
+		{name: "ZeroWidth", layer: &Layer{Width: 0, Height: 1, Cells: []Cell{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Marshal(newXpFile(*tt.layer, t), SaveOptions{}); err == nil {
+				t.Fatal("Expected error marshaling inconsistent layer, got nil")
+			}
+		})
+	}
+}
+
+// newSyntheticXPFile returns an XPFile with the given number of layers of the given dimensions, filled with varying
+// glyphs and colors.
+func newSyntheticXPFile(width, height, layers int, tb testing.TB) *XPFile {
+	tb.Helper()
+
+	xp := &XPFile{Version: -1}
+	for l := 0; l < layers; l++ {
+		layer := NewEmptyLayer(width, height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				i := uint8(x + y + l)
+				layer.Set(x, y, Cell{
+					Rune: CP437Decoder(int32(i%255) + 1),
+					Fg:   Color{R: i, G: i * 3, B: i * 7},
+					Bg:   Color{R: i * 5, G: i * 11, B: i * 13},
+				})
+			}
+		}
+		xp.AddLayer(*layer)
+	}
+	return xp
+}
+
+// benchmarkInputs returns the plain and gzipped encodings of the test data files and of large synthetic maps.
+func benchmarkInputs(b *testing.B) map[string][]byte {
+	b.Helper()
+
+	inputs := map[string][]byte{}
+
+	files, err := filepath.Glob(filepath.Join(testDataDir, "*.xp"))
+	if err != nil {
+		b.Fatalf("Failed to list test data: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			b.Fatalf("Failed to read %q: %v", file, err)
+		}
+		inputs[filepath.Base(file)] = data
+	}
+
+	for _, size := range []struct{ width, height, layers int }{{80, 25, 1}, {200, 200, 9}} {
+		plain, err := Marshal(newSyntheticXPFile(size.width, size.height, size.layers, b), SaveOptions{RuneEncoder: CP437Encoder})
+		if err != nil {
+			b.Fatalf("Failed to marshal synthetic XP file: %v", err)
+		}
+		gzipped, err := GzipData(plain, flate.BestCompression)
+		if err != nil {
+			b.Fatalf("Failed to gzip synthetic XP file: %v", err)
+		}
+
+		name := fmt.Sprintf("synthetic_%dx%dx%d", size.width, size.height, size.layers)
+		inputs[name+"_plain.xp"] = plain
+		inputs[name+".xp"] = gzipped
+	}
+
+	return inputs
+}
+
+func BenchmarkLoadXPFromReader(b *testing.B) {
+	inputs := benchmarkInputs(b)
+
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := inputs[name]
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := LoadXPFromReader(bytes.NewReader(data), LoadOptions{RuneDecoder: CP437Decoder}); err != nil {
+					b.Fatalf("Failed to load %q: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for _, size := range []struct{ width, height, layers int }{{10, 15, 1}, {80, 25, 1}, {200, 200, 9}} {
+		xp := newSyntheticXPFile(size.width, size.height, size.layers, b)
+		opts := SaveOptions{RuneEncoder: CP437Encoder}
+
+		b.Run(fmt.Sprintf("%dx%dx%d", size.width, size.height, size.layers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Marshal(xp, opts); err != nil {
+					b.Fatalf("Failed to marshal: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSaveXPToWriter(b *testing.B) {
+	xp := newSyntheticXPFile(200, 200, 9, b)
+	opts := SaveOptions{Gzip: true, GzipLevel: flate.BestSpeed, RuneEncoder: CP437Encoder}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := SaveXPToWriter(io.Discard, xp, opts); err != nil {
+			b.Fatalf("Failed to save: %v", err)
+		}
+	}
+}
+
+func TestMarshalLargeLayerRoundTrip(t *testing.T) {
+	// Use dimensions that do not divide evenly into the internal chunk size.
+	xp := newSyntheticXPFile(123, 77, 2, t)
+
+	data, err := Marshal(xp, SaveOptions{RuneEncoder: CP437Encoder})
+	if err != nil {
+		t.Fatalf("Failed to marshal XP file: %v", err)
+	}
+
+	if expected := 8 + 2*(8+123*77*cellSize); len(data) != expected {
+		t.Fatalf("Expected %d bytes, got %d", expected, len(data))
+	}
+
+	for _, columnMajor := range []bool{false, true} {
+		xpReloaded, err := LoadXPFromReader(bytes.NewReader(data), LoadOptions{ColumnMajor: columnMajor, RuneDecoder: CP437Decoder})
+		if err != nil {
+			t.Fatalf("ColumnMajor=%v: failed to reload XP file: %v", columnMajor, err)
+		}
+
+		for i, layer := range xpReloaded.Layers {
+			for y := 0; y < int(layer.Height); y++ {
+				for x := 0; x < int(layer.Width); x++ {
+					if got, want := layer.GetCell(x, y), xp.Layers[i].GetCell(x, y); got != want {
+						t.Fatalf("ColumnMajor=%v: layer %d, cell (%d,%d): expected %+v, got %+v", columnMajor, i, x, y, want, got)
+					}
+				}
+			}
+		}
+	}
+}